@@ -2,11 +2,13 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
 	"github.com/ReesavGupta/discord-mcp-server/internal/config"
 	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
 	"github.com/sirupsen/logrus"
@@ -14,8 +16,18 @@ import (
 
 func main() {
 	configPath := flag.String("config", "configs/config.yaml", "Path to configuration file")
+	verifyAudit := flag.String("verify-audit", "", "Verify the hash chain of an audit log and exit")
 	flag.Parse()
 
+	if *verifyAudit != "" {
+		count, head, err := auth.VerifyAuditLog(*verifyAudit)
+		if err != nil {
+			log.Fatalf("Audit log verification failed after %d records: %v", count, err)
+		}
+		fmt.Printf("Audit log OK: %d records, head hash %s\n", count, head)
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/redact"
+	"github.com/sirupsen/logrus"
+)
+
+// maxAuditLineSize bounds a single audit record when reading the log back.
+const maxAuditLineSize = 16 << 20
+
+// AuditEntry is a single audited event.
+type AuditEntry struct {
+	Time       time.Time   `json:"time"`
+	Event      string      `json:"event"`
+	UserID     string      `json:"user_id,omitempty"`
+	Method     string      `json:"method,omitempty"`
+	Tool       string      `json:"tool,omitempty"`
+	Arguments  interface{} `json:"arguments,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Success    bool        `json:"success"`
+	DurationMS int64       `json:"duration_ms,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// auditRecord is one line of the audit log. Each record's hash covers its
+// sequence number, the previous record's hash and the exact entry bytes, so
+// editing, removing or reordering any record breaks the chain from that
+// point on.
+type auditRecord struct {
+	Seq      uint64          `json:"seq"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+	Entry    json.RawMessage `json:"entry"`
+}
+
+// AuditLogger appends audit entries to a hash-chained JSONL file. Without a
+// file it falls back to the shared logger.
+type AuditLogger struct {
+	logger   *logrus.Logger
+	redactor *redact.Redactor
+
+	mu       sync.Mutex
+	file     *os.File
+	seq      uint64
+	lastHash string
+}
+
+// NewAuditLogger opens the audit log at path for appending, continuing the
+// hash chain of any records already in it. An empty path logs through
+// logger instead.
+func NewAuditLogger(path string, logger *logrus.Logger) (*AuditLogger, error) {
+	al := &AuditLogger{logger: logger}
+	if path == "" {
+		return al, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	last, err := lastAuditRecord(path)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		al.seq = last.Seq
+		al.lastHash = last.Hash
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	al.file = file
+
+	return al, nil
+}
+
+// SetRedactor sets the redactor applied to entries before they are
+// written.
+func (al *AuditLogger) SetRedactor(redactor *redact.Redactor) {
+	if al != nil {
+		al.redactor = redactor
+	}
+}
+
+func (al *AuditLogger) LogAuth(method, identifier string, success bool) {
+	al.Log(AuditEntry{
+		Event:   "authentication",
+		Method:  method,
+		UserID:  identifier,
+		Success: success,
+	})
+}
+
+func (al *AuditLogger) LogOperation(operation, userID string, data interface{}) {
+	al.Log(AuditEntry{
+		Event:   operation,
+		UserID:  userID,
+		Success: true,
+		Data:    data,
+	})
+}
+
+// LogToolCall records a tools/call request and its outcome.
+func (al *AuditLogger) LogToolCall(tool, userID string, args, result interface{}, callErr error, duration time.Duration) {
+	entry := AuditEntry{
+		Event:      "tool_call",
+		UserID:     userID,
+		Tool:       tool,
+		Arguments:  args,
+		Result:     result,
+		Success:    callErr == nil,
+		DurationMS: duration.Milliseconds(),
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	al.Log(entry)
+}
+
+// Log appends entry to the audit log. It is safe to call on a nil
+// AuditLogger, which discards the entry.
+func (al *AuditLogger) Log(entry AuditEntry) {
+	if al == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if al.redactor != nil {
+		entry.Arguments = al.redactor.Value("arguments", entry.Arguments)
+		entry.Result = al.redactor.Value("result", entry.Result)
+		entry.Data = al.redactor.Value("data", entry.Data)
+		redactedErr := al.redactor.Value("error", entry.Error)
+		if s, ok := redactedErr.(string); ok {
+			entry.Error = s
+		} else {
+			entry.Error = fmt.Sprint(redactedErr)
+		}
+	}
+
+	if al.file == nil {
+		al.logger.WithFields(logrus.Fields{
+			"event":   entry.Event,
+			"user_id": entry.UserID,
+			"method":  entry.Method,
+			"tool":    entry.Tool,
+			"success": entry.Success,
+			"error":   entry.Error,
+		}).Info("Audit event")
+		return
+	}
+
+	if err := al.append(entry); err != nil {
+		al.logger.WithError(err).Error("Failed to write audit log")
+	}
+}
+
+func (al *AuditLogger) append(entry AuditEntry) error {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	record := auditRecord{
+		Seq:      al.seq + 1,
+		PrevHash: al.lastHash,
+		Entry:    entryJSON,
+	}
+	record.Hash = auditHash(record.Seq, record.PrevHash, record.Entry)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := al.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := al.file.Sync(); err != nil {
+		return err
+	}
+
+	al.seq = record.Seq
+	al.lastHash = record.Hash
+	return nil
+}
+
+// Close closes the audit log file.
+func (al *AuditLogger) Close() error {
+	if al == nil || al.file == nil {
+		return nil
+	}
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.file.Close()
+}
+
+func auditHash(seq uint64, prevHash string, entry []byte) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatUint(seq, 10)))
+	h.Write([]byte{'|'})
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'|'})
+	h.Write(entry)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyAuditLog checks the hash chain of the audit log at path, returning
+// the number of records verified and the hash of the last one. Publishing
+// that hash somewhere outside the server lets later checks prove the log
+// was only ever appended to.
+func VerifyAuditLog(path string) (int, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditLineSize)
+
+	var count, line int
+	var prevHash string
+	for scanner.Scan() {
+		line++
+		if blankAuditLine(scanner.Bytes()) {
+			continue
+		}
+
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return count, prevHash, fmt.Errorf("line %d: malformed record: %w", line, err)
+		}
+
+		if record.Seq != uint64(count+1) {
+			return count, prevHash, fmt.Errorf("line %d: expected seq %d, found %d", line, count+1, record.Seq)
+		}
+		if record.PrevHash != prevHash {
+			return count, prevHash, fmt.Errorf("record %d: previous hash does not match record %d", record.Seq, count)
+		}
+		if auditHash(record.Seq, record.PrevHash, record.Entry) != record.Hash {
+			return count, prevHash, fmt.Errorf("record %d: hash mismatch, entry was modified", record.Seq)
+		}
+
+		count++
+		prevHash = record.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return count, prevHash, err
+	}
+
+	return count, prevHash, nil
+}
+
+// blankAuditLine reports whether line holds no record. Blank lines, such
+// as a trailing newline left by an editor, are skipped wherever the log is
+// read; they cannot hide a change, as the records around them still have
+// to chain.
+func blankAuditLine(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+// lastAuditRecord returns the final record in the audit log at path, or
+// nil if the log does not exist yet or is empty.
+func lastAuditRecord(path string) (*auditRecord, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditLineSize)
+
+	var last []byte
+	for scanner.Scan() {
+		if !blankAuditLine(scanner.Bytes()) {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if last == nil {
+		return nil, nil
+	}
+
+	var record auditRecord
+	if err := json.Unmarshal(last, &record); err != nil {
+		return nil, fmt.Errorf("audit log %s ends with a malformed record: %w", path, err)
+	}
+	return &record, nil
+}
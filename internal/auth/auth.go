@@ -3,18 +3,22 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/ReesavGupta/discord-mcp-server/internal/redact"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
 )
 
 type AuthManager struct {
-	jwtSecret []byte
-	apiKeys   map[string]bool
-	logger    *logrus.Logger
-	auditor   *AuditLogger
+	jwtSecret         []byte
+	apiKeys           map[string]bool
+	apiKeyPermissions []string
+	logger            *logrus.Logger
+	auditor           *AuditLogger
 }
 
 type Claims struct {
@@ -22,35 +26,71 @@ type Claims struct {
 	Permissions []string `json:"permissions"`
 	BotID       string   `json:"bot_id"`
 	jwt.RegisteredClaims
-}
 
-type AuditLogger struct {
-	logger *logrus.Logger
+	// Method records how the caller authenticated. It is set by the server
+	// and never read from a token.
+	Method string `json:"-"`
 }
 
+// PermissionAll grants every permission.
+const PermissionAll = "*"
+
+// Authentication methods recorded in Claims.Method
+const (
+	MethodJWT       = "jwt_token"
+	MethodAPIKey    = "api_key"
+	MethodTrusted   = "trusted"
+	MethodAnonymous = "anonymous"
+)
+
+// ErrUnauthenticated is returned when a caller presents no credentials.
+var ErrUnauthenticated = errors.New("authentication required")
+
 func NewAuthManager(jwtSecret string, apiKeys []string, logger *logrus.Logger, enableAudit bool, auditPath string) (*AuthManager, error) {
 	keyMap := make(map[string]bool)
 	for _, key := range apiKeys {
-		keyMap[key] = true
+		if key != "" {
+			keyMap[key] = true
+		}
 	}
 
 	var auditor *AuditLogger
 	if enableAudit {
-		auditor = &AuditLogger{logger: logger}
+		var err error
+		if auditor, err = NewAuditLogger(auditPath, logger); err != nil {
+			return nil, err
+		}
 	}
 
 	return &AuthManager{
-		jwtSecret: []byte(jwtSecret),
-		apiKeys:   keyMap,
-		logger:    logger,
-		auditor:   auditor,
+		jwtSecret:         []byte(jwtSecret),
+		apiKeys:           keyMap,
+		apiKeyPermissions: []string{PermissionAll},
+		logger:            logger,
+		auditor:           auditor,
 	}, nil
 }
 
+// Auditor returns the audit logger, or nil if auditing is disabled.
+func (am *AuthManager) Auditor() *AuditLogger {
+	return am.auditor
+}
+
+// Close releases the audit log.
+func (am *AuthManager) Close() error {
+	return am.auditor.Close()
+}
+
+// SetAPIKeyPermissions sets the permissions granted to callers that
+// authenticate with an API key. API keys grant every permission by default.
+func (am *AuthManager) SetAPIKeyPermissions(permissions []string) {
+	am.apiKeyPermissions = permissions
+}
+
 func (am *AuthManager) ValidateAPIKey(apiKey string) bool {
 	valid := am.apiKeys[apiKey]
 	if am.auditor != nil {
-		am.auditor.LogAuth("api_key", apiKey, valid)
+		am.auditor.LogAuth(MethodAPIKey, "api_key:"+KeyFingerprint(apiKey), valid)
 	}
 	return valid
 }
@@ -72,44 +112,92 @@ func (am *AuthManager) GenerateToken(userID string, permissions []string, botID
 }
 
 func (am *AuthManager) ValidateToken(tokenString string) (*Claims, error) {
+	if len(am.jwtSecret) == 0 {
+		return nil, fmt.Errorf("JWT authentication is not configured")
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return am.jwtSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
+		if am.auditor != nil {
+			am.auditor.LogAuth(MethodJWT, "", false)
+		}
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		if am.auditor != nil {
-			am.auditor.LogAuth("jwt_token", claims.UserID, true)
+			am.auditor.LogAuth(MethodJWT, claims.UserID, true)
 		}
+		claims.Method = MethodJWT
 		return claims, nil
 	}
 
 	return nil, fmt.Errorf("invalid token")
 }
 
-func (am *AuthManager) GenerateAPIKey() string {
-	bytes := make([]byte, 32)
-	rand.Read(bytes)
-	return hex.EncodeToString(bytes)
+// Authenticate resolves the caller behind a request's credentials. A bearer
+// credential that looks like a JWT is validated as one; any other bearer
+// value is treated as an API key.
+func (am *AuthManager) Authenticate(bearer, apiKey string) (*Claims, error) {
+	if bearer != "" {
+		if strings.Count(bearer, ".") == 2 {
+			return am.ValidateToken(bearer)
+		}
+		apiKey = bearer
+	}
+
+	if apiKey == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	if !am.ValidateAPIKey(apiKey) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	return &Claims{
+		UserID:      "api_key:" + KeyFingerprint(apiKey),
+		Permissions: am.apiKeyPermissions,
+		Method:      MethodAPIKey,
+	}, nil
 }
 
-func (al *AuditLogger) LogAuth(method, identifier string, success bool) {
-	al.logger.WithFields(logrus.Fields{
-		"method":     method,
-		"identifier": identifier,
-		"success":    success,
-		"timestamp":  time.Now(),
-	}).Info("Authentication attempt")
+// TrustedClaims returns the claims for a caller that needs no credentials,
+// such as the local stdio client.
+func TrustedClaims(userID string) *Claims {
+	return &Claims{UserID: userID, Permissions: []string{PermissionAll}, Method: MethodTrusted}
 }
 
-func (al *AuditLogger) LogOperation(operation, userID string, data interface{}) {
-	al.logger.WithFields(logrus.Fields{
-		"operation": operation,
-		"user_id":   userID,
-		"data":      data,
-		"timestamp": time.Now(),
-	}).Info("Operation performed")
+// AnonymousClaims returns the claims for an HTTP caller that presented no
+// credentials, which is only allowed when authentication is not required.
+func AnonymousClaims(permissions []string) *Claims {
+	return &Claims{UserID: "anonymous", Permissions: permissions, Method: MethodAnonymous}
+}
+
+// HasPermission reports whether the claims grant permission. "*" grants
+// every permission and "scope:*" grants every permission in that scope.
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == PermissionAll || p == permission {
+			return true
+		}
+		if scope, ok := strings.CutSuffix(p, ":*"); ok && strings.HasPrefix(permission, scope+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyFingerprint returns a short, stable identifier for an API key that is
+// safe to log.
+func KeyFingerprint(apiKey string) string {
+	return redact.Fingerprint(apiKey)
+}
+
+func (am *AuthManager) GenerateAPIKey() string {
+	bytes := make([]byte, 32)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
 }
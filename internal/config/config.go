@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,6 +15,7 @@ type Config struct {
 	Auth    AuthConfig    `yaml:"auth"`
 	Logging LoggingConfig `yaml:"logging"`
 	MCP     MCPConfig     `yaml:"mcp"`
+	Index   IndexConfig   `yaml:"index"`
 }
 
 type ServerConfig struct {
@@ -22,28 +25,106 @@ type ServerConfig struct {
 }
 
 type DiscordConfig struct {
-	BotToken     string   `yaml:"bot_token"`
-	GuildID      string   `yaml:"guild_id"`
-	AllowedRoles []string `yaml:"allowed_roles"`
+	BotToken     string        `yaml:"bot_token"`
+	GuildID      string        `yaml:"guild_id"`
+	AllowedRoles []string      `yaml:"allowed_roles"`
+	RoleCacheTTL time.Duration `yaml:"role_cache_ttl"`
+	MaxHistory   int           `yaml:"max_history"`
+
+	// AttachmentDir is the directory send_message may read attachment
+	// files from. Local files cannot be attached if it is empty.
+	AttachmentDir string `yaml:"attachment_dir"`
+
+	// ModerationDryRun turns every moderate_content action that would
+	// change something into a dry run, as if called with dry_run set.
+	ModerationDryRun bool `yaml:"moderation_dry_run"`
+}
+
+// IndexConfig configures the local message index. Channels lists the
+// channels to index; if it is empty every channel the bot can see is
+// indexed. Backfill is how many past messages of each listed channel are
+// fetched at startup.
+type IndexConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Path     string   `yaml:"path"`
+	Channels []string `yaml:"channels"`
+	Backfill int      `yaml:"backfill"`
 }
 
 type AuthConfig struct {
-	JWTSecret    string   `yaml:"jwt_secret"`
-	APIKeys      []string `yaml:"api_keys"`
-	EnableAudit  bool     `yaml:"enable_audit"`
-	AuditLogPath string   `yaml:"audit_log_path"`
+	Required          bool     `yaml:"required"`
+	JWTSecret         string   `yaml:"jwt_secret"`
+	APIKeys           []string `yaml:"api_keys"`
+	APIKeyPermissions []string `yaml:"api_key_permissions"`
+	EnableAudit       bool     `yaml:"enable_audit"`
+	AuditLogPath      string   `yaml:"audit_log_path"`
+
+	// AnonymousPermissions are granted to HTTP callers without credentials
+	// when Required is off. Only read permissions may be listed; none are
+	// granted by default.
+	AnonymousPermissions []string `yaml:"anonymous_permissions"`
 }
 
 type LoggingConfig struct {
-	Level    string `yaml:"level"`
-	Format   string `yaml:"format"`
-	FilePath string `yaml:"file_path"`
+	Level     string          `yaml:"level"`
+	Format    string          `yaml:"format"`
+	FilePath  string          `yaml:"file_path"`
+	Redaction RedactionConfig `yaml:"redaction"`
+}
+
+// RedactionConfig controls how secrets, personal data and message content
+// are scrubbed from logs and audit records.
+type RedactionConfig struct {
+	Enabled          bool     `yaml:"enabled"`
+	ContentMode      string   `yaml:"content_mode"`
+	MaxContentLength int      `yaml:"max_content_length"`
+	SecretFields     []string `yaml:"secret_fields"`
+	ContentFields    []string `yaml:"content_fields"`
+	PIIFields        []string `yaml:"pii_fields"`
+	ScrubPatterns    bool     `yaml:"scrub_patterns"`
 }
 
 type MCPConfig struct {
-	ProtocolVersion string `yaml:"protocol_version"`
-	Transport       string `yaml:"transport"`
-	Debug           bool   `yaml:"debug"`
+	ProtocolVersion string         `yaml:"protocol_version"`
+	Transport       string         `yaml:"transport"`
+	Debug           bool           `yaml:"debug"`
+	HTTP            HTTPConfig     `yaml:"http"`
+	Prompts         []PromptConfig `yaml:"prompts"`
+}
+
+// HTTPConfig configures the Streamable HTTP transport, used when
+// mcp.transport is "http".
+type HTTPConfig struct {
+	Addr           string        `yaml:"addr"`
+	Path           string        `yaml:"path"`
+	SessionTimeout time.Duration `yaml:"session_timeout"`
+	AllowedOrigins []string      `yaml:"allowed_origins"`
+}
+
+// PromptConfig defines an extra prompt served alongside the built-in ones.
+// Template is a Go text/template rendered with the prompt's arguments.
+type PromptConfig struct {
+	Name           string                 `yaml:"name"`
+	Description    string                 `yaml:"description"`
+	Arguments      []PromptArgumentConfig `yaml:"arguments"`
+	Template       string                 `yaml:"template"`
+	IncludeHistory bool                   `yaml:"include_history"`
+}
+
+type PromptArgumentConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// envRefPattern matches ${VAR} references. Bare $VAR is left alone so that
+// prompt templates and secrets may contain a literal "$".
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func expandEnv(data []byte) []byte {
+	return envRefPattern.ReplaceAllFunc(data, func(ref []byte) []byte {
+		return []byte(os.Getenv(string(envRefPattern.FindSubmatch(ref)[1])))
+	})
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -53,10 +134,21 @@ func LoadConfig(path string) (*Config, error) {
 	config.Server.Name = "discord-mcp-server"
 	config.Server.Version = "1.0.0"
 	config.Server.Environment = "development"
-	config.MCP.ProtocolVersion = "2024-11-05"
+	config.MCP.ProtocolVersion = "2025-06-18"
 	config.MCP.Transport = "stdio"
+	config.MCP.HTTP.Addr = "127.0.0.1:8080"
+	config.MCP.HTTP.Path = "/mcp"
+	config.MCP.HTTP.SessionTimeout = 30 * time.Minute
+	config.Discord.RoleCacheTTL = 5 * time.Minute
+	config.Discord.MaxHistory = 1000
+	config.Auth.Required = true
+	config.Index.Path = "data/index.db"
 	config.Logging.Level = "info"
 	config.Logging.Format = "json"
+	config.Logging.Redaction.Enabled = true
+	config.Logging.Redaction.ContentMode = "truncate"
+	config.Logging.Redaction.MaxContentLength = 64
+	config.Logging.Redaction.ScrubPatterns = true
 
 	// Load from file if exists
 	if _, err := os.Stat(path); err == nil {
@@ -65,6 +157,10 @@ func LoadConfig(path string) (*Config, error) {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 
+		// Expand ${VAR} references so placeholder secrets such as
+		// "${API_KEY_1}" are never accepted literally.
+		file = expandEnv(file)
+
 		if err := yaml.Unmarshal(file, config); err != nil {
 			return nil, fmt.Errorf("failed to parse config file: %w", err)
 		}
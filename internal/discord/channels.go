@@ -0,0 +1,181 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+var channelTypeNames = map[discordgo.ChannelType]string{
+	discordgo.ChannelTypeGuildText:          "text",
+	discordgo.ChannelTypeDM:                 "dm",
+	discordgo.ChannelTypeGuildVoice:         "voice",
+	discordgo.ChannelTypeGroupDM:            "group_dm",
+	discordgo.ChannelTypeGuildCategory:      "category",
+	discordgo.ChannelTypeGuildNews:          "announcement",
+	discordgo.ChannelTypeGuildStore:         "store",
+	discordgo.ChannelTypeGuildNewsThread:    "announcement_thread",
+	discordgo.ChannelTypeGuildPublicThread:  "public_thread",
+	discordgo.ChannelTypeGuildPrivateThread: "private_thread",
+	discordgo.ChannelTypeGuildStageVoice:    "stage",
+	discordgo.ChannelTypeGuildDirectory:     "directory",
+	discordgo.ChannelTypeGuildForum:         "forum",
+	discordgo.ChannelTypeGuildMedia:         "media",
+}
+
+// ChannelTypeName returns a readable name for a channel type, such as
+// "text" or "forum".
+func ChannelTypeName(t discordgo.ChannelType) string {
+	if name, ok := channelTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// IsForum reports whether channel is a forum or media channel, whose
+// threads are posts with tags.
+func IsForum(channel *discordgo.Channel) bool {
+	return channel.Type == discordgo.ChannelTypeGuildForum || channel.Type == discordgo.ChannelTypeGuildMedia
+}
+
+// ChannelSettings are the channel fields EditChannel can change. Nil
+// fields are left as they are; an empty Topic or ParentID clears it.
+type ChannelSettings struct {
+	Name     *string
+	Topic    *string
+	NSFW     *bool
+	Slowmode *int
+	ParentID *string
+	Position *int
+}
+
+// ChannelPosition moves a channel to Position, and into the category
+// ParentID if it is set.
+type ChannelPosition struct {
+	ID       string `json:"id"`
+	Position int    `json:"position"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// CreateChannel creates a channel in guildID. reason is recorded in the
+// guild's audit log.
+func (c *Client) CreateChannel(ctx context.Context, guildID string, data discordgo.GuildChannelCreateData, reason string) (*discordgo.Channel, error) {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id":  guildID,
+		"name":      data.Name,
+		"type":      ChannelTypeName(data.Type),
+		"parent_id": data.ParentID,
+		"reason":    reason,
+	}).Info("Creating channel")
+	return c.session.GuildChannelCreateComplex(guildID, data, c.auditOptions(ctx, reason)...)
+}
+
+// EditChannel changes a channel's settings.
+func (c *Client) EditChannel(ctx context.Context, channelID string, settings ChannelSettings, reason string) (*discordgo.Channel, error) {
+	// discordgo.ChannelEdit cannot clear the topic or parent, so the
+	// payload is built here with explicit nulls
+	payload := make(map[string]interface{})
+	if settings.Name != nil {
+		payload["name"] = *settings.Name
+	}
+	if settings.Topic != nil {
+		payload["topic"] = nullIfEmpty(*settings.Topic)
+	}
+	if settings.NSFW != nil {
+		payload["nsfw"] = *settings.NSFW
+	}
+	if settings.Slowmode != nil {
+		payload["rate_limit_per_user"] = *settings.Slowmode
+	}
+	if settings.ParentID != nil {
+		payload["parent_id"] = nullIfEmpty(*settings.ParentID)
+	}
+	if settings.Position != nil {
+		payload["position"] = *settings.Position
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"fields":     len(payload),
+		"reason":     reason,
+	}).Info("Editing channel")
+
+	endpoint := discordgo.EndpointChannel(channelID)
+	body, err := c.session.RequestWithBucketID(http.MethodPatch, endpoint, payload, endpoint, c.auditOptions(ctx, reason)...)
+	if err != nil {
+		return nil, err
+	}
+	var channel discordgo.Channel
+	if err := json.Unmarshal(body, &channel); err != nil {
+		return nil, fmt.Errorf("failed to decode channel: %w", err)
+	}
+	return &channel, nil
+}
+
+// DeleteChannel deletes a channel, or closes a thread.
+func (c *Client) DeleteChannel(ctx context.Context, channelID, reason string) (*discordgo.Channel, error) {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"reason":     reason,
+	}).Info("Deleting channel")
+	return c.session.ChannelDelete(channelID, c.auditOptions(ctx, reason)...)
+}
+
+// ReorderChannels moves channels within guildID. Channels not listed keep
+// their relative order.
+func (c *Client) ReorderChannels(ctx context.Context, guildID string, positions []ChannelPosition, reason string) error {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"channels": len(positions),
+		"reason":   reason,
+	}).Info("Reordering channels")
+
+	// discordgo's GuildChannelsReorder cannot move channels between
+	// categories
+	endpoint := discordgo.EndpointGuildChannels(guildID)
+	_, err := c.session.RequestWithBucketID(http.MethodPatch, endpoint, positions, endpoint, c.auditOptions(ctx, reason)...)
+	return err
+}
+
+// auditOptions returns the request options for a call that should record
+// reason in the guild's audit log.
+func (c *Client) auditOptions(ctx context.Context, reason string) []discordgo.RequestOption {
+	options := []discordgo.RequestOption{discordgo.WithContext(ctx)}
+	if reason != "" {
+		options = append(options, discordgo.WithAuditLogReason(url.PathEscape(reason)))
+	}
+	return options
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// creatableChannelTypes are the channel types CreateChannel accepts, by
+// name.
+var creatableChannelTypes = map[string]discordgo.ChannelType{
+	"text":         discordgo.ChannelTypeGuildText,
+	"voice":        discordgo.ChannelTypeGuildVoice,
+	"category":     discordgo.ChannelTypeGuildCategory,
+	"announcement": discordgo.ChannelTypeGuildNews,
+	"stage":        discordgo.ChannelTypeGuildStageVoice,
+	"forum":        discordgo.ChannelTypeGuildForum,
+	"media":        discordgo.ChannelTypeGuildMedia,
+}
+
+// ParseChannelType returns the channel type named name, as returned by
+// ChannelTypeName, if channels of that type can be created.
+func ParseChannelType(name string) (discordgo.ChannelType, error) {
+	if t, ok := creatableChannelTypes[name]; ok {
+		return t, nil
+	}
+	return 0, fmt.Errorf("cannot create channels of type %q", name)
+}
@@ -1,7 +1,9 @@
 package discord
 
 import (
+	"context"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,17 +15,49 @@ type Client struct {
 	session *discordgo.Session
 	logger  *logrus.Logger
 	guildID string
+	roles   *roleCache
+
+	maxHistory int
 }
 
+// MessageFilter selects messages for SearchMessages. The channels searched
+// are ChannelID and ChannelIDs, or every channel in GuildID if neither is
+// set.
 type MessageFilter struct {
-	ChannelID string
-	UserID    string
-	Content   string
-	Before    *time.Time
-	After     *time.Time
-	Limit     int
+	ChannelID  string
+	ChannelIDs []string
+	GuildID    string
+	UserID     string
+	Content    string
+	Before     *time.Time
+	After      *time.Time
+	Limit      int
+
+	// Pattern, if set, must match the message content.
+	Pattern *regexp.Regexp
+
+	// Nil pointers leave these properties unfiltered.
+	HasAttachment *bool
+	HasLink       *bool
+	HasEmbed      *bool
+	Pinned        *bool
+
+	MentionsUser string
+	MentionsRole string
+
+	// AuthorType restricts results to AuthorBot or AuthorHuman messages.
+	AuthorType string
 }
 
+// Author types for MessageFilter.AuthorType
+const (
+	AuthorBot   = "bot"
+	AuthorHuman = "human"
+)
+
+// linkPattern matches URLs in message content.
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://\S+`)
+
 func NewClient(token string, logger *logrus.Logger) (*Client, error) {
 	session, err := discordgo.New("Bot " + token)
 	if err != nil {
@@ -32,6 +66,9 @@ func NewClient(token string, logger *logrus.Logger) (*Client, error) {
 	return &Client{
 		session: session,
 		logger:  logger,
+		roles:   newRoleCache(defaultRoleCacheTTL),
+
+		maxHistory: defaultMaxHistory,
 	}, nil
 }
 
@@ -39,6 +76,7 @@ func (c *Client) Connect() error {
 	c.session.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		c.logger.Info("Discord bot is ready")
 	})
+	c.addRoleCacheHandlers()
 	return c.session.Open()
 }
 
@@ -46,46 +84,31 @@ func (c *Client) Disconnect() error {
 	return c.session.Close()
 }
 
-func (c *Client) SendMessage(channelID, content string) (*discordgo.Message, error) {
-	c.logger.WithFields(logrus.Fields{
-		"channel_id": channelID,
-		"content":    content,
-	}).Info("Sending message")
-	return c.session.ChannelMessageSend(channelID, content)
-}
-
-func (c *Client) GetMessages(channelID string, limit int) ([]*discordgo.Message, error) {
-	c.logger.WithFields(logrus.Fields{
-		"channel_id": channelID,
-		"limit":      limit,
-	}).Info("Fetching messages")
-	return c.session.ChannelMessages(channelID, limit, "", "", "")
+// SendMessage sends a message, which may include embeds, attachments and a
+// reply reference, to a channel.
+func (c *Client) SendMessage(ctx context.Context, channelID string, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	fields := logrus.Fields{
+		"channel_id":  channelID,
+		"content":     msg.Content,
+		"embeds":      len(msg.Embeds),
+		"attachments": len(msg.Files),
+	}
+	if msg.Reference != nil {
+		fields["reply_to"] = msg.Reference.MessageID
+	}
+	c.logger.WithFields(fields).Info("Sending message")
+	return c.session.ChannelMessageSendComplex(channelID, msg, discordgo.WithContext(ctx))
 }
 
-func (c *Client) GetChannelInfo(channelID string) (*discordgo.Channel, error) {
+func (c *Client) GetChannelInfo(ctx context.Context, channelID string) (*discordgo.Channel, error) {
 	c.logger.WithFields(logrus.Fields{
 		"channel_id": channelID,
 	}).Info("Fetching channel info")
-	return c.session.Channel(channelID)
-}
-
-func (c *Client) SearchMessages(filter MessageFilter) ([]*discordgo.Message, error) {
-	messages, err := c.GetMessages(filter.ChannelID, filter.Limit)
-	if err != nil {
-		return nil, err
-	}
-
-	var filtered []*discordgo.Message
-	for _, msg := range messages {
-		if c.matchesFilter(msg, filter) {
-			filtered = append(filtered, msg)
-		}
-	}
-	return filtered, nil
+	return c.session.Channel(channelID, discordgo.WithContext(ctx))
 }
 
 func (c *Client) matchesFilter(msg *discordgo.Message, filter MessageFilter) bool {
-	if filter.UserID != "" && msg.Author.ID != filter.UserID {
+	if filter.UserID != "" && (msg.Author == nil || msg.Author.ID != filter.UserID) {
 		return false
 	}
 
@@ -104,34 +127,135 @@ func (c *Client) matchesFilter(msg *discordgo.Message, filter MessageFilter) boo
 		return false
 	}
 
+	if filter.Pattern != nil && !filter.Pattern.MatchString(msg.Content) {
+		return false
+	}
+
+	if !flagMatches(filter.HasAttachment, len(msg.Attachments) > 0) ||
+		!flagMatches(filter.HasLink, linkPattern.MatchString(msg.Content)) ||
+		!flagMatches(filter.HasEmbed, len(msg.Embeds) > 0) ||
+		!flagMatches(filter.Pinned, msg.Pinned) {
+		return false
+	}
+
+	if filter.MentionsUser != "" && !mentionsUser(msg, filter.MentionsUser) {
+		return false
+	}
+
+	if filter.MentionsRole != "" && !containsString(msg.MentionRoles, filter.MentionsRole) {
+		return false
+	}
+
+	switch filter.AuthorType {
+	case AuthorBot:
+		return msg.Author != nil && msg.Author.Bot
+	case AuthorHuman:
+		return msg.Author != nil && !msg.Author.Bot && msg.WebhookID == ""
+	}
+
 	return true
 }
 
-func (c *Client) DeleteMessage(channelID, messageID string) error {
+func flagMatches(want *bool, got bool) bool {
+	return want == nil || *want == got
+}
+
+func mentionsUser(msg *discordgo.Message, userID string) bool {
+	for _, user := range msg.Mentions {
+		if user.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, channelID, messageID string) error {
 	c.logger.WithFields(logrus.Fields{
 		"channel_id": channelID,
 		"message_id": messageID,
 	}).Info("Deleting message")
-	return c.session.ChannelMessageDelete(channelID, messageID)
+	return c.session.ChannelMessageDelete(channelID, messageID, discordgo.WithContext(ctx))
 }
 
-func (c *Client) KickUser(guildID, userID, reason string) error {
+func (c *Client) KickUser(ctx context.Context, guildID, userID, reason string) error {
 	c.logger.WithFields(logrus.Fields{
 		"guild_id": guildID,
 		"user_id":  userID,
 		"reason":   reason,
 	}).Info("Kicking user")
-	return c.session.GuildMemberDeleteWithReason(guildID, userID, reason)
+	return c.session.GuildMemberDeleteWithReason(guildID, userID, reason, discordgo.WithContext(ctx))
 }
 
-func (c *Client) BanUser(guildID, userID, reason string, deleteMessageDays int) error {
+func (c *Client) BanUser(ctx context.Context, guildID, userID, reason string, deleteMessageDays int) error {
 	c.logger.WithFields(logrus.Fields{
 		"guild_id":            guildID,
 		"user_id":             userID,
 		"reason":              reason,
 		"delete_message_days": deleteMessageDays,
 	}).Info("Banning user")
-	return c.session.GuildBanCreateWithReason(guildID, userID, reason, deleteMessageDays)
+	return c.session.GuildBanCreateWithReason(guildID, userID, reason, deleteMessageDays, discordgo.WithContext(ctx))
+}
+
+// MaxTimeout is the longest Discord lets a member be timed out for.
+const MaxTimeout = 28 * 24 * time.Hour
+
+// TimeoutUser stops userID from talking or reacting in guildID until the
+// given time. A nil until lifts an existing timeout.
+func (c *Client) TimeoutUser(ctx context.Context, guildID, userID string, until *time.Time, reason string) error {
+	fields := logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+		"reason":   reason,
+	}
+	if until != nil {
+		fields["until"] = until.Format(time.RFC3339)
+		c.logger.WithFields(fields).Info("Timing out user")
+	} else {
+		c.logger.WithFields(fields).Info("Removing user timeout")
+	}
+	return c.session.GuildMemberTimeout(guildID, userID, until, c.auditOptions(ctx, reason)...)
+}
+
+// UnbanUser lifts userID's ban from guildID.
+func (c *Client) UnbanUser(ctx context.Context, guildID, userID, reason string) error {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+		"reason":   reason,
+	}).Info("Unbanning user")
+	return c.session.GuildBanDelete(guildID, userID, c.auditOptions(ctx, reason)...)
+}
+
+// GetBans returns up to limit of guildID's bans in user ID order, starting
+// after the user with ID after if it is set.
+func (c *Client) GetBans(ctx context.Context, guildID string, limit int, after string) ([]*discordgo.GuildBan, error) {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"limit":    limit,
+		"after":    after,
+	}).Info("Fetching bans")
+	return c.session.GuildBans(guildID, limit, "", after, discordgo.WithContext(ctx))
+}
+
+// SetNickname sets userID's nickname in guildID. An empty nickname resets
+// it to their username.
+func (c *Client) SetNickname(ctx context.Context, guildID, userID, nickname, reason string) error {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+		"nickname": nickname,
+		"reason":   reason,
+	}).Info("Setting nickname")
+	return c.session.GuildMemberNickname(guildID, userID, nickname, c.auditOptions(ctx, reason)...)
 }
 
 // Additional helper methods for better functionality
@@ -143,19 +267,24 @@ func (c *Client) GetGuildID() string {
 	return c.guildID
 }
 
-// Get guild channels
-func (c *Client) GetGuildChannels(guildID string) ([]*discordgo.Channel, error) {
+// Get guild info, including approximate member counts
+func (c *Client) GetGuildInfo(ctx context.Context, guildID string) (*discordgo.Guild, error) {
 	c.logger.WithFields(logrus.Fields{
 		"guild_id": guildID,
-	}).Info("Fetching guild channels")
-	return c.session.GuildChannels(guildID)
+	}).Info("Fetching guild info")
+	return c.session.GuildWithCounts(guildID, discordgo.WithContext(ctx))
 }
 
-// Get guild members
-func (c *Client) GetGuildMembers(guildID string, limit int) ([]*discordgo.Member, error) {
+// Get the guilds the bot is a member of
+func (c *Client) GetGuilds(ctx context.Context) ([]*discordgo.UserGuild, error) {
+	c.logger.Info("Fetching guilds")
+	return c.session.UserGuilds(200, "", "", false, discordgo.WithContext(ctx))
+}
+
+// Get guild channels
+func (c *Client) GetGuildChannels(ctx context.Context, guildID string) ([]*discordgo.Channel, error) {
 	c.logger.WithFields(logrus.Fields{
 		"guild_id": guildID,
-		"limit":    limit,
-	}).Info("Fetching guild members")
-	return c.session.GuildMembers(guildID, "", limit)
+	}).Info("Fetching guild channels")
+	return c.session.GuildChannels(guildID, discordgo.WithContext(ctx))
 }
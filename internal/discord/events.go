@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Message event types
+const (
+	MessageCreated = "create"
+	MessageUpdated = "update"
+	MessageDeleted = "delete"
+)
+
+// MessageEvent describes a change to a channel's messages received from the
+// gateway. Message is nil for deletions.
+type MessageEvent struct {
+	Type       string
+	GuildID    string
+	ChannelID  string
+	MessageIDs []string
+	Message    *discordgo.Message
+}
+
+// AddMessageHandler registers handler to be called for every message
+// created, edited or deleted in a channel the bot can see. Handlers run on
+// the gateway's event goroutines and should not block.
+func (c *Client) AddMessageHandler(handler func(MessageEvent)) {
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		handler(MessageEvent{
+			Type:       MessageCreated,
+			GuildID:    m.GuildID,
+			ChannelID:  m.ChannelID,
+			MessageIDs: []string{m.ID},
+			Message:    m.Message,
+		})
+	})
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+		handler(MessageEvent{
+			Type:       MessageUpdated,
+			GuildID:    m.GuildID,
+			ChannelID:  m.ChannelID,
+			MessageIDs: []string{m.ID},
+			Message:    m.Message,
+		})
+	})
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageDelete) {
+		handler(MessageEvent{
+			Type:       MessageDeleted,
+			GuildID:    m.GuildID,
+			ChannelID:  m.ChannelID,
+			MessageIDs: []string{m.ID},
+		})
+	})
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+		handler(MessageEvent{
+			Type:       MessageDeleted,
+			GuildID:    m.GuildID,
+			ChannelID:  m.ChannelID,
+			MessageIDs: m.Messages,
+		})
+	})
+}
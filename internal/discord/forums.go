@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// maxForumTags is the most tags a forum channel can offer.
+const maxForumTags = 20
+
+// ForumPostOptions describes a new forum post.
+type ForumPostOptions struct {
+	Title   string
+	Message *discordgo.MessageSend
+
+	// TagIDs are the IDs of the forum's tags to apply, see ResolveForumTags.
+	TagIDs []string
+
+	AutoArchiveMinutes int
+}
+
+// CreateForumPost creates a post, a thread with a starter message, in a
+// forum channel.
+func (c *Client) CreateForumPost(ctx context.Context, channelID string, post ForumPostOptions) (*discordgo.Channel, error) {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"title":      post.Title,
+		"content":    post.Message.Content,
+		"tags":       post.TagIDs,
+	}).Info("Creating forum post")
+
+	return c.session.ForumThreadStartComplex(channelID, &discordgo.ThreadStart{
+		Name:                post.Title,
+		AutoArchiveDuration: post.AutoArchiveMinutes,
+		AppliedTags:         post.TagIDs,
+	}, post.Message, discordgo.WithContext(ctx))
+}
+
+// SetForumTags replaces the tags a forum channel offers. Tags without an
+// ID are created.
+func (c *Client) SetForumTags(ctx context.Context, channelID string, tags []discordgo.ForumTag) (*discordgo.Channel, error) {
+	if len(tags) > maxForumTags {
+		return nil, fmt.Errorf("a forum can have at most %d tags", maxForumTags)
+	}
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"tags":       len(tags),
+	}).Info("Updating forum tags")
+	return c.session.ChannelEditComplex(channelID, &discordgo.ChannelEdit{
+		AvailableTags: &tags,
+	}, discordgo.WithContext(ctx))
+}
+
+// FindForumTag returns the forum tag whose ID or name, ignoring case,
+// is ref.
+func FindForumTag(forum *discordgo.Channel, ref string) (discordgo.ForumTag, bool) {
+	for _, tag := range forum.AvailableTags {
+		if tag.ID == ref || strings.EqualFold(tag.Name, ref) {
+			return tag, true
+		}
+	}
+	return discordgo.ForumTag{}, false
+}
+
+// ResolveForumTags maps tag names or IDs to the IDs of forum's tags.
+func ResolveForumTags(forum *discordgo.Channel, refs []string) ([]string, error) {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		tag, ok := FindForumTag(forum, ref)
+		if !ok {
+			return nil, fmt.Errorf("forum %s has no tag %q", forum.ID, ref)
+		}
+		ids = append(ids, tag.ID)
+	}
+	return ids, nil
+}
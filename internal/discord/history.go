@@ -0,0 +1,145 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// messagePageSize is the most messages Discord returns per request.
+	messagePageSize = 100
+
+	// defaultMaxHistory bounds how many messages a single GetMessages call
+	// pages through.
+	defaultMaxHistory = 1000
+)
+
+// HistoryOptions selects which part of a channel's history GetMessages
+// fetches. At most one of Before, After and Around may be set; with none
+// set the most recent messages are returned.
+type HistoryOptions struct {
+	Before string
+	After  string
+	Around string
+	Limit  int
+}
+
+// SetMaxHistory sets the most messages a single GetMessages call returns.
+func (c *Client) SetMaxHistory(max int) {
+	c.maxHistory = max
+}
+
+// GetMessages fetches up to opts.Limit messages from a channel, newest
+// first, paging through history as needed. It also returns the cursor to
+// continue from: the ID to pass as After when paging forward with After,
+// and as Before otherwise. The cursor is empty once there is no more
+// history in that direction.
+func (c *Client) GetMessages(ctx context.Context, channelID string, opts HistoryOptions) ([]*discordgo.Message, string, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > c.maxHistory {
+		limit = c.maxHistory
+	}
+
+	set := 0
+	for _, cursor := range []string{opts.Before, opts.After, opts.Around} {
+		if cursor != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, "", fmt.Errorf("only one of before, after and around may be set")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"limit":      limit,
+		"before":     opts.Before,
+		"after":      opts.After,
+		"around":     opts.Around,
+	}).Info("Fetching messages")
+
+	// Discord only returns a single page around a message
+	if opts.Around != "" {
+		if limit > messagePageSize {
+			limit = messagePageSize
+		}
+		messages, err := c.session.ChannelMessages(channelID, limit, "", "", opts.Around, discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, "", err
+		}
+		sortNewestFirst(messages)
+		var cursor string
+		if len(messages) > 0 {
+			cursor = messages[len(messages)-1].ID
+		}
+		return messages, cursor, nil
+	}
+
+	forward := opts.After != ""
+	cursor := opts.Before
+	if forward {
+		cursor = opts.After
+	}
+
+	var messages []*discordgo.Message
+	for len(messages) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+
+		pageSize := limit - len(messages)
+		if pageSize > messagePageSize {
+			pageSize = messagePageSize
+		}
+
+		var page []*discordgo.Message
+		var err error
+		if forward {
+			page, err = c.session.ChannelMessages(channelID, pageSize, "", cursor, "", discordgo.WithContext(ctx))
+		} else {
+			page, err = c.session.ChannelMessages(channelID, pageSize, cursor, "", "", discordgo.WithContext(ctx))
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		sortNewestFirst(page)
+		messages = append(messages, page...)
+
+		if len(page) < pageSize {
+			// History is exhausted in this direction
+			sortNewestFirst(messages)
+			return messages, "", nil
+		}
+
+		if forward {
+			cursor = page[0].ID
+		} else {
+			cursor = page[len(page)-1].ID
+		}
+	}
+
+	sortNewestFirst(messages)
+	return messages, cursor, nil
+}
+
+// sortNewestFirst orders messages by descending snowflake ID, which is
+// also creation order.
+func sortNewestFirst(messages []*discordgo.Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return snowflakeValue(messages[i].ID) > snowflakeValue(messages[j].ID)
+	})
+}
+
+func snowflakeValue(id string) uint64 {
+	v, _ := strconv.ParseUint(id, 10, 64)
+	return v
+}
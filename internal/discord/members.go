@@ -0,0 +1,64 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// maxMembersPage is the most members Discord returns per request.
+const maxMembersPage = 1000
+
+// GetGuildMembers returns up to limit members of guildID in user ID order,
+// starting after the member with ID after if it is set. Members are fetched
+// a page at a time until limit is reached or the list runs out.
+func (c *Client) GetGuildMembers(ctx context.Context, guildID, after string, limit int) ([]*discordgo.Member, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"after":    after,
+		"limit":    limit,
+	}).Info("Fetching guild members")
+
+	var members []*discordgo.Member
+	for len(members) < limit {
+		pageSize := min(limit-len(members), maxMembersPage)
+		page, err := c.session.GuildMembers(guildID, after, pageSize, discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if len(page) < pageSize {
+			break
+		}
+		after = page[len(page)-1].User.ID
+	}
+	return members, nil
+}
+
+// SearchMembers returns up to limit members of guildID whose username or
+// nickname starts with query.
+func (c *Client) SearchMembers(ctx context.Context, guildID, query string, limit int) ([]*discordgo.Member, error) {
+	if limit <= 0 || limit > maxMembersPage {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxMembersPage)
+	}
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"query":    query,
+		"limit":    limit,
+	}).Info("Searching guild members")
+	return c.session.GuildMembersSearch(guildID, query, limit, discordgo.WithContext(ctx))
+}
+
+// GetMember returns userID's membership of guildID.
+func (c *Client) GetMember(ctx context.Context, guildID, userID string) (*discordgo.Member, error) {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+	}).Info("Fetching guild member")
+	return c.session.GuildMember(guildID, userID, discordgo.WithContext(ctx))
+}
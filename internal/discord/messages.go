@@ -0,0 +1,244 @@
+package discord
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReactionUsers is the most users Discord returns per reactions request.
+const maxReactionUsers = 100
+
+const (
+	// bulkDeleteMaxAge is the age beyond which Discord refuses to bulk
+	// delete messages. A minute is kept in hand for clock skew.
+	bulkDeleteMaxAge = 14*24*time.Hour - time.Minute
+
+	// bulkDeleteBatch is the most messages one bulk delete request takes.
+	bulkDeleteBatch = 100
+)
+
+// BulkDeleteResult reports what DeleteMessages deleted and how.
+type BulkDeleteResult struct {
+	// Bulk counts messages deleted with the bulk delete endpoint, Single
+	// those too old for it and deleted one at a time.
+	Bulk   int
+	Single int
+
+	Failures []DeleteFailure
+}
+
+// DeleteFailure is a message DeleteMessages could not delete.
+type DeleteFailure struct {
+	ChannelID string
+	MessageID string
+	Err       error
+}
+
+// GetMessage fetches a single message.
+func (c *Client) GetMessage(ctx context.Context, channelID, messageID string) (*discordgo.Message, error) {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": messageID,
+	}).Info("Fetching message")
+	return c.session.ChannelMessage(channelID, messageID, discordgo.WithContext(ctx))
+}
+
+// EditMessage edits a message. The bot can only change the content of its
+// own messages.
+func (c *Client) EditMessage(ctx context.Context, edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	fields := logrus.Fields{
+		"channel_id": edit.Channel,
+		"message_id": edit.ID,
+	}
+	if edit.Content != nil {
+		fields["content"] = *edit.Content
+	}
+	c.logger.WithFields(fields).Info("Editing message")
+	return c.session.ChannelMessageEditComplex(edit, discordgo.WithContext(ctx))
+}
+
+func (c *Client) PinMessage(ctx context.Context, channelID, messageID string) error {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": messageID,
+	}).Info("Pinning message")
+	return c.session.ChannelMessagePin(channelID, messageID, discordgo.WithContext(ctx))
+}
+
+func (c *Client) UnpinMessage(ctx context.Context, channelID, messageID string) error {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": messageID,
+	}).Info("Unpinning message")
+	return c.session.ChannelMessageUnpin(channelID, messageID, discordgo.WithContext(ctx))
+}
+
+// AddReaction reacts to a message as the bot. emoji is a unicode emoji or
+// a custom emoji in any of the forms accepted by EmojiAPIName.
+func (c *Client) AddReaction(ctx context.Context, channelID, messageID, emoji string) error {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": messageID,
+		"emoji":      emoji,
+	}).Info("Adding reaction")
+	return c.session.MessageReactionAdd(channelID, messageID, EmojiAPIName(emoji), discordgo.WithContext(ctx))
+}
+
+// RemoveReaction removes a reaction from a message. An empty userID removes
+// the bot's own reaction.
+func (c *Client) RemoveReaction(ctx context.Context, channelID, messageID, emoji, userID string) error {
+	if userID == "" {
+		userID = "@me"
+	}
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": messageID,
+		"emoji":      emoji,
+		"user_id":    userID,
+	}).Info("Removing reaction")
+	return c.session.MessageReactionRemove(channelID, messageID, EmojiAPIName(emoji), userID, discordgo.WithContext(ctx))
+}
+
+// GetReactionUsers returns up to limit users who reacted to a message with
+// emoji, starting after the user ID after.
+func (c *Client) GetReactionUsers(ctx context.Context, channelID, messageID, emoji string, limit int, after string) ([]*discordgo.User, error) {
+	if limit <= 0 || limit > maxReactionUsers {
+		limit = maxReactionUsers
+	}
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": messageID,
+		"emoji":      emoji,
+		"limit":      limit,
+	}).Info("Fetching reactions")
+	return c.session.MessageReactions(channelID, messageID, EmojiAPIName(emoji), limit, "", after, discordgo.WithContext(ctx))
+}
+
+// EmojiAPIName converts an emoji as written in a message, such as "👍",
+// "<:party:123>" or "<a:party:123>", to the "name:id" form the API expects.
+func EmojiAPIName(emoji string) string {
+	emoji = strings.TrimSpace(emoji)
+	if strings.HasPrefix(emoji, "<") && strings.HasSuffix(emoji, ">") {
+		emoji = strings.TrimPrefix(strings.Trim(emoji, "<>"), "a:")
+		emoji = strings.TrimPrefix(emoji, ":")
+	}
+	return emoji
+}
+
+// DeleteMessages deletes messages, which may be in several channels.
+// Messages under two weeks old are deleted in batches with Discord's bulk
+// delete endpoint and older ones one at a time. Failures are reported
+// rather than stopping the run, unless ctx is done.
+func (c *Client) DeleteMessages(ctx context.Context, messages []*discordgo.Message, reason string) BulkDeleteResult {
+	var result BulkDeleteResult
+	fail := func(channelID, messageID string, err error) {
+		result.Failures = append(result.Failures, DeleteFailure{ChannelID: channelID, MessageID: messageID, Err: err})
+	}
+
+	plan := planDeletes(messages)
+	c.logger.WithFields(logrus.Fields{
+		"messages": len(messages),
+		"channels": len(plan),
+		"reason":   reason,
+	}).Info("Deleting messages")
+
+	for _, channel := range plan {
+		for _, batch := range channel.batches {
+			var err error
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			} else if len(batch) == 1 {
+				// The bulk endpoint needs at least two messages
+				err = c.session.ChannelMessageDelete(channel.id, batch[0], c.auditOptions(ctx, reason)...)
+			} else {
+				err = c.session.ChannelMessagesBulkDelete(channel.id, batch, c.auditOptions(ctx, reason)...)
+			}
+			if err != nil {
+				for _, id := range batch {
+					fail(channel.id, id, err)
+				}
+				continue
+			}
+			if len(batch) == 1 {
+				result.Single++
+			} else {
+				result.Bulk += len(batch)
+			}
+		}
+
+		for _, id := range channel.old {
+			err := ctx.Err()
+			if err == nil {
+				err = c.session.ChannelMessageDelete(channel.id, id, c.auditOptions(ctx, reason)...)
+			}
+			if err != nil {
+				fail(channel.id, id, err)
+				continue
+			}
+			result.Single++
+		}
+	}
+	return result
+}
+
+// PlanDeletes counts how DeleteMessages would delete messages, without
+// deleting anything.
+func PlanDeletes(messages []*discordgo.Message) BulkDeleteResult {
+	var result BulkDeleteResult
+	for _, channel := range planDeletes(messages) {
+		for _, batch := range channel.batches {
+			if len(batch) == 1 {
+				result.Single++
+			} else {
+				result.Bulk += len(batch)
+			}
+		}
+		result.Single += len(channel.old)
+	}
+	return result
+}
+
+// channelDeletes are the deletes DeleteMessages makes in one channel:
+// batches of recent messages for the bulk delete endpoint, and messages
+// too old for it.
+type channelDeletes struct {
+	id      string
+	batches [][]string
+	old     []string
+}
+
+// planDeletes groups messages by channel, in the order channels are first
+// seen, and splits them into bulk delete batches and old messages.
+func planDeletes(messages []*discordgo.Message) []*channelDeletes {
+	var plan []*channelDeletes
+	byID := make(map[string]*channelDeletes)
+	recent := make(map[string][]string)
+	cutoff := time.Now().Add(-bulkDeleteMaxAge)
+	for _, msg := range messages {
+		channel, ok := byID[msg.ChannelID]
+		if !ok {
+			channel = &channelDeletes{id: msg.ChannelID}
+			byID[msg.ChannelID] = channel
+			plan = append(plan, channel)
+		}
+		if msg.Timestamp.After(cutoff) {
+			recent[msg.ChannelID] = append(recent[msg.ChannelID], msg.ID)
+		} else {
+			channel.old = append(channel.old, msg.ID)
+		}
+	}
+
+	for _, channel := range plan {
+		ids := recent[channel.id]
+		for len(ids) > 0 {
+			batch := ids[:min(len(ids), bulkDeleteBatch)]
+			ids = ids[len(batch):]
+			channel.batches = append(channel.batches, batch)
+		}
+	}
+	return plan
+}
@@ -0,0 +1,116 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// CheckModeration checks, as Discord would, that the bot could act on
+// userID in guildID with permission: the bot must hold the permission, and
+// a member it acts on must rank below the bot's highest role. userID may
+// be empty to check the permission alone. It returns the member, or nil if
+// userID is not in the guild.
+func (c *Client) CheckModeration(ctx context.Context, guildID, userID string, permission int64) (*discordgo.Member, error) {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+	}).Debug("Checking moderation permissions")
+
+	bot, err := c.botRank(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if !bot.isAdmin() && bot.permissions&permission != permission {
+		return nil, fmt.Errorf("the bot lacks the %s permission", strings.Join(PermissionNames(permission), ", "))
+	}
+	if userID == "" {
+		return nil, nil
+	}
+
+	member, err := c.session.GuildMember(guildID, userID, discordgo.WithContext(ctx))
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+
+	// The @everyone role sits at position 0, below every other role
+	top, permissions := 0, int64(0)
+	for _, role := range bot.roles {
+		if role.ID != guildID && !containsString(member.Roles, role.ID) {
+			continue
+		}
+		permissions |= role.Permissions
+		top = max(top, role.Position)
+	}
+	if bot.top == nil || top >= bot.top.Position {
+		return nil, fmt.Errorf("user %s's highest role is not below the bot's highest role", userID)
+	}
+	if permission == discordgo.PermissionModerateMembers && permissions&discordgo.PermissionAdministrator != 0 {
+		return nil, fmt.Errorf("user %s is an administrator and cannot be timed out", userID)
+	}
+	return member, nil
+}
+
+// CheckMessageDelete fetches a message and checks that the bot could
+// delete it: its own messages always, anyone else's with the
+// manage_messages permission.
+func (c *Client) CheckMessageDelete(ctx context.Context, channelID, messageID string) (*discordgo.Message, error) {
+	msg, err := c.GetMessage(ctx, channelID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	botID, err := c.botUserID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify the bot user: %w", err)
+	}
+	if msg.Author != nil && msg.Author.ID == botID {
+		return msg, nil
+	}
+
+	channel, err := c.GetChannelInfo(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	if channel.GuildID == "" {
+		return nil, fmt.Errorf("the bot can only delete its own messages outside a guild")
+	}
+	if _, err := c.CheckModeration(ctx, channel.GuildID, "", discordgo.PermissionManageMessages); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// GetBan returns userID's ban from guildID, or nil if they are not banned.
+func (c *Client) GetBan(ctx context.Context, guildID, userID string) (*discordgo.GuildBan, error) {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+	}).Debug("Fetching ban")
+	ban, err := c.session.GuildBan(guildID, userID, discordgo.WithContext(ctx))
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	return ban, err
+}
+
+// IsNotFound reports whether err is Discord saying that what was asked for
+// does not exist, such as an unknown channel, guild or message.
+func IsNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	if restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound {
+		return true
+	}
+	// The 10xxx codes are Discord's "Unknown ..." errors
+	return restErr.Message != nil && restErr.Message.Code >= 10000 && restErr.Message.Code < 11000
+}
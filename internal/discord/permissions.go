@@ -0,0 +1,103 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// permissionNames names Discord's permission bits as in the API
+// documentation, lowercased.
+var permissionNames = map[int64]string{
+	discordgo.PermissionCreateInstantInvite:              "create_instant_invite",
+	discordgo.PermissionKickMembers:                      "kick_members",
+	discordgo.PermissionBanMembers:                       "ban_members",
+	discordgo.PermissionAdministrator:                    "administrator",
+	discordgo.PermissionManageChannels:                   "manage_channels",
+	discordgo.PermissionManageGuild:                      "manage_guild",
+	discordgo.PermissionAddReactions:                     "add_reactions",
+	discordgo.PermissionViewAuditLogs:                    "view_audit_log",
+	discordgo.PermissionVoicePrioritySpeaker:             "priority_speaker",
+	discordgo.PermissionVoiceStreamVideo:                 "stream",
+	discordgo.PermissionViewChannel:                      "view_channel",
+	discordgo.PermissionSendMessages:                     "send_messages",
+	discordgo.PermissionSendTTSMessages:                  "send_tts_messages",
+	discordgo.PermissionManageMessages:                   "manage_messages",
+	discordgo.PermissionEmbedLinks:                       "embed_links",
+	discordgo.PermissionAttachFiles:                      "attach_files",
+	discordgo.PermissionReadMessageHistory:               "read_message_history",
+	discordgo.PermissionMentionEveryone:                  "mention_everyone",
+	discordgo.PermissionUseExternalEmojis:                "use_external_emojis",
+	discordgo.PermissionViewGuildInsights:                "view_guild_insights",
+	discordgo.PermissionVoiceConnect:                     "connect",
+	discordgo.PermissionVoiceSpeak:                       "speak",
+	discordgo.PermissionVoiceMuteMembers:                 "mute_members",
+	discordgo.PermissionVoiceDeafenMembers:               "deafen_members",
+	discordgo.PermissionVoiceMoveMembers:                 "move_members",
+	discordgo.PermissionVoiceUseVAD:                      "use_vad",
+	discordgo.PermissionChangeNickname:                   "change_nickname",
+	discordgo.PermissionManageNicknames:                  "manage_nicknames",
+	discordgo.PermissionManageRoles:                      "manage_roles",
+	discordgo.PermissionManageWebhooks:                   "manage_webhooks",
+	discordgo.PermissionManageGuildExpressions:           "manage_guild_expressions",
+	discordgo.PermissionUseApplicationCommands:           "use_application_commands",
+	discordgo.PermissionVoiceRequestToSpeak:              "request_to_speak",
+	discordgo.PermissionManageEvents:                     "manage_events",
+	discordgo.PermissionManageThreads:                    "manage_threads",
+	discordgo.PermissionCreatePublicThreads:              "create_public_threads",
+	discordgo.PermissionCreatePrivateThreads:             "create_private_threads",
+	discordgo.PermissionUseExternalStickers:              "use_external_stickers",
+	discordgo.PermissionSendMessagesInThreads:            "send_messages_in_threads",
+	discordgo.PermissionUseEmbeddedActivities:            "use_embedded_activities",
+	discordgo.PermissionModerateMembers:                  "moderate_members",
+	discordgo.PermissionUseSoundboard:                    "use_soundboard",
+	discordgo.PermissionCreateGuildExpressions:           "create_guild_expressions",
+	discordgo.PermissionCreateEvents:                     "create_events",
+	discordgo.PermissionUseExternalSounds:                "use_external_sounds",
+	discordgo.PermissionSendVoiceMessages:                "send_voice_messages",
+	discordgo.PermissionSendPolls:                        "send_polls",
+	discordgo.PermissionUseExternalApps:                  "use_external_apps",
+	discordgo.PermissionViewCreatorMonetizationAnalytics: "view_creator_monetization_analytics",
+}
+
+// PermissionNames lists the names of the permissions set in bits, sorted.
+// Unknown bits are named by number, e.g. "bit_51".
+func PermissionNames(bits int64) []string {
+	names := []string{}
+	for bit := 0; bit < 63; bit++ {
+		mask := int64(1) << bit
+		if bits&mask == 0 {
+			continue
+		}
+		if name, ok := permissionNames[mask]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("bit_%d", bit))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePermissions returns the permission bits named in names, using the
+// names PermissionNames returns.
+func ParsePermissions(names []string) (int64, error) {
+	var bits int64
+	for _, name := range names {
+		bit, ok := permissionBits[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown permission %q", name)
+		}
+		bits |= bit
+	}
+	return bits, nil
+}
+
+var permissionBits = func() map[string]int64 {
+	bits := make(map[string]int64, len(permissionNames))
+	for bit, name := range permissionNames {
+		bits[name] = bit
+	}
+	return bits
+}()
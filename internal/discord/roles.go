@@ -0,0 +1,454 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultRoleCacheTTL is how long a member's roles are trusted before they
+// are looked up again.
+const defaultRoleCacheTTL = 5 * time.Minute
+
+// MemberRole identifies one of a member's roles.
+type MemberRole struct {
+	ID   string
+	Name string
+}
+
+type roleCacheEntry struct {
+	roles   []MemberRole
+	expires time.Time
+}
+
+// roleCache caches member role lookups per guild.
+type roleCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]map[string]roleCacheEntry
+}
+
+func newRoleCache(ttl time.Duration) *roleCache {
+	return &roleCache{
+		ttl:     ttl,
+		entries: make(map[string]map[string]roleCacheEntry),
+	}
+}
+
+func (rc *roleCache) get(guildID, userID string) ([]MemberRole, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[guildID][userID]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.roles, true
+}
+
+func (rc *roleCache) put(guildID, userID string, roles []MemberRole) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.entries[guildID] == nil {
+		rc.entries[guildID] = make(map[string]roleCacheEntry)
+	}
+	rc.entries[guildID][userID] = roleCacheEntry{
+		roles:   roles,
+		expires: time.Now().Add(rc.ttl),
+	}
+}
+
+func (rc *roleCache) invalidateMember(guildID, userID string) {
+	rc.mu.Lock()
+	delete(rc.entries[guildID], userID)
+	rc.mu.Unlock()
+}
+
+func (rc *roleCache) invalidateGuild(guildID string) {
+	rc.mu.Lock()
+	delete(rc.entries, guildID)
+	rc.mu.Unlock()
+}
+
+// SetRoleCacheTTL sets how long member role lookups are cached.
+func (c *Client) SetRoleCacheTTL(ttl time.Duration) {
+	c.roles.mu.Lock()
+	c.roles.ttl = ttl
+	c.roles.mu.Unlock()
+}
+
+// GetMemberRoles returns the roles userID holds in guildID. Results are
+// cached, and the cache is invalidated by member and role gateway events.
+func (c *Client) GetMemberRoles(ctx context.Context, guildID, userID string) ([]MemberRole, error) {
+	if roles, ok := c.roles.get(guildID, userID); ok {
+		return roles, nil
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+	}).Debug("Fetching member roles")
+
+	member, err := c.session.GuildMember(guildID, userID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	guildRoles, err := c.session.GuildRoles(guildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(guildRoles))
+	for _, role := range guildRoles {
+		names[role.ID] = role.Name
+	}
+
+	roles := make([]MemberRole, 0, len(member.Roles))
+	for _, id := range member.Roles {
+		roles = append(roles, MemberRole{ID: id, Name: names[id]})
+	}
+
+	c.roles.put(guildID, userID, roles)
+	return roles, nil
+}
+
+// MemberHasAnyRole reports whether userID holds one of allowed in guildID.
+// Entries in allowed may be role names (matched case-insensitively) or
+// role IDs.
+func (c *Client) MemberHasAnyRole(ctx context.Context, guildID, userID string, allowed []string) (bool, error) {
+	roles, err := c.GetMemberRoles(ctx, guildID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range roles {
+		for _, want := range allowed {
+			if role.ID == want || strings.EqualFold(role.Name, want) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (c *Client) addRoleCacheHandlers() {
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		if m.Member != nil && m.User != nil {
+			c.roles.invalidateMember(m.GuildID, m.User.ID)
+		}
+	})
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		if m.Member != nil && m.User != nil {
+			c.roles.invalidateMember(m.GuildID, m.User.ID)
+		}
+	})
+	c.session.AddHandler(func(s *discordgo.Session, r *discordgo.GuildRoleUpdate) {
+		if r.GuildRole != nil {
+			c.roles.invalidateGuild(r.GuildID)
+		}
+	})
+	c.session.AddHandler(func(s *discordgo.Session, r *discordgo.GuildRoleDelete) {
+		c.roles.invalidateGuild(r.GuildID)
+	})
+}
+
+// GetGuildRoles returns the roles defined in guildID.
+func (c *Client) GetGuildRoles(ctx context.Context, guildID string) ([]*discordgo.Role, error) {
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+	}).Debug("Fetching guild roles")
+	return c.session.GuildRoles(guildID, discordgo.WithContext(ctx))
+}
+
+// FindRole returns the role whose ID or name, ignoring case, is ref.
+func FindRole(roles []*discordgo.Role, ref string) (*discordgo.Role, bool) {
+	for _, role := range roles {
+		if role.ID == ref {
+			return role, true
+		}
+	}
+	for _, role := range roles {
+		if strings.EqualFold(role.Name, ref) {
+			return role, true
+		}
+	}
+	return nil, false
+}
+
+// CreateRole creates a role in guildID. It refuses to grant permissions the
+// bot does not hold itself, as Discord would. actorID, if set, is the member
+// the change is made for, who is held to the same checks.
+func (c *Client) CreateRole(ctx context.Context, guildID string, params *discordgo.RoleParams, reason, actorID string) (*discordgo.Role, error) {
+	ranks, err := c.ranks(ctx, guildID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ranks {
+		if err := r.canGrant(params.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"name":     params.Name,
+		"reason":   reason,
+	}).Info("Creating role")
+	return c.session.GuildRoleCreate(guildID, params, c.auditOptions(ctx, reason)...)
+}
+
+// EditRole changes a role in guildID, which must be below the bot's highest
+// role, and below actorID's if set.
+func (c *Client) EditRole(ctx context.Context, guildID, roleID string, params *discordgo.RoleParams, reason, actorID string) (*discordgo.Role, error) {
+	ranks, err := c.ranks(ctx, guildID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := ranks[0].role(roleID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ranks {
+		if err := r.canManage(role); err != nil {
+			return nil, err
+		}
+		if err := r.canGrant(params.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"role_id":  roleID,
+		"reason":   reason,
+	}).Info("Editing role")
+	edited, err := c.session.GuildRoleEdit(guildID, roleID, params, c.auditOptions(ctx, reason)...)
+	if err != nil {
+		return nil, err
+	}
+	c.roles.invalidateGuild(guildID)
+	return edited, nil
+}
+
+// DeleteRole deletes a role in guildID, which must be below the bot's
+// highest role, and below actorID's if set.
+func (c *Client) DeleteRole(ctx context.Context, guildID, roleID, reason, actorID string) error {
+	ranks, err := c.ranks(ctx, guildID, actorID)
+	if err != nil {
+		return err
+	}
+	role, err := ranks[0].role(roleID)
+	if err != nil {
+		return err
+	}
+	if role.ID == guildID {
+		return fmt.Errorf("the @everyone role cannot be deleted")
+	}
+	for _, r := range ranks {
+		if err := r.canManage(role); err != nil {
+			return err
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"role_id":  roleID,
+		"reason":   reason,
+	}).Info("Deleting role")
+	if err := c.session.GuildRoleDelete(guildID, roleID, c.auditOptions(ctx, reason)...); err != nil {
+		return err
+	}
+	c.roles.invalidateGuild(guildID)
+	return nil
+}
+
+// AddMemberRole gives userID a role in guildID, which must be below the
+// bot's highest role, and below actorID's if set.
+func (c *Client) AddMemberRole(ctx context.Context, guildID, userID, roleID, reason, actorID string) error {
+	if err := c.checkAssignable(ctx, guildID, roleID, actorID); err != nil {
+		return err
+	}
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+		"role_id":  roleID,
+		"reason":   reason,
+	}).Info("Adding member role")
+	if err := c.session.GuildMemberRoleAdd(guildID, userID, roleID, c.auditOptions(ctx, reason)...); err != nil {
+		return err
+	}
+	c.roles.invalidateMember(guildID, userID)
+	return nil
+}
+
+// RemoveMemberRole takes a role in guildID, which must be below the bot's
+// highest role, and below actorID's if set, from userID.
+func (c *Client) RemoveMemberRole(ctx context.Context, guildID, userID, roleID, reason, actorID string) error {
+	if err := c.checkAssignable(ctx, guildID, roleID, actorID); err != nil {
+		return err
+	}
+	c.logger.WithFields(logrus.Fields{
+		"guild_id": guildID,
+		"user_id":  userID,
+		"role_id":  roleID,
+		"reason":   reason,
+	}).Info("Removing member role")
+	if err := c.session.GuildMemberRoleRemove(guildID, userID, roleID, c.auditOptions(ctx, reason)...); err != nil {
+		return err
+	}
+	c.roles.invalidateMember(guildID, userID)
+	return nil
+}
+
+func (c *Client) checkAssignable(ctx context.Context, guildID, roleID, actorID string) error {
+	ranks, err := c.ranks(ctx, guildID, actorID)
+	if err != nil {
+		return err
+	}
+	role, err := ranks[0].role(roleID)
+	if err != nil {
+		return err
+	}
+	if role.ID == guildID {
+		return fmt.Errorf("every member has the @everyone role")
+	}
+	if role.Managed {
+		return fmt.Errorf("role %s is managed by an integration and cannot be assigned", role.Name)
+	}
+	for _, r := range ranks {
+		if err := r.canManage(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rank is a member's standing in a guild's role hierarchy: the bot's, or
+// that of the member a change is made for.
+type rank struct {
+	who         string
+	roles       []*discordgo.Role
+	top         *discordgo.Role
+	permissions int64
+	// owner is set for the guild owner, who is above the hierarchy
+	owner bool
+}
+
+// botRank works out the bot's highest role and guild permissions in
+// guildID.
+func (c *Client) botRank(ctx context.Context, guildID string) (*rank, error) {
+	botID, err := c.botUserID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify the bot user: %w", err)
+	}
+	member, err := c.session.GuildMember(guildID, botID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the bot's roles: %w", err)
+	}
+	roles, err := c.GetGuildRoles(ctx, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild roles: %w", err)
+	}
+	return newRank("the bot", guildID, member, roles), nil
+}
+
+// ranks returns the bot's rank in guildID followed, if actorID is set, by
+// the rank of the member the change is made for. A change must pass the
+// checks for each, as Discord would check the member making it themselves.
+func (c *Client) ranks(ctx context.Context, guildID, actorID string) ([]*rank, error) {
+	bot, err := c.botRank(ctx, guildID)
+	if err != nil || actorID == "" {
+		return []*rank{bot}, err
+	}
+
+	member, err := c.session.GuildMember(guildID, actorID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the caller's roles: %w", err)
+	}
+	guild, err := c.session.Guild(guildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild: %w", err)
+	}
+	actor := newRank("the caller", guildID, member, bot.roles)
+	actor.owner = guild.OwnerID == actorID
+	return []*rank{bot, actor}, nil
+}
+
+// newRank works out member's highest role and guild permissions from the
+// guild's roles.
+func newRank(who, guildID string, member *discordgo.Member, roles []*discordgo.Role) *rank {
+	r := &rank{who: who, roles: roles}
+	for _, role := range roles {
+		// Every member has the @everyone role, whose ID is the guild's
+		if role.ID != guildID && !containsString(member.Roles, role.ID) {
+			continue
+		}
+		r.permissions |= role.Permissions
+		if r.top == nil || role.Position > r.top.Position {
+			r.top = role
+		}
+	}
+	return r
+}
+
+// botUserID returns the bot's user ID.
+func (c *Client) botUserID(ctx context.Context) (string, error) {
+	if user := c.session.State.User; user != nil {
+		return user.ID, nil
+	}
+	user, err := c.session.User("@me", discordgo.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+func (r *rank) role(roleID string) (*discordgo.Role, error) {
+	for _, role := range r.roles {
+		if role.ID == roleID {
+			return role, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown role %s", roleID)
+}
+
+func (r *rank) isAdmin() bool {
+	return r.owner || r.permissions&discordgo.PermissionAdministrator != 0
+}
+
+// canManage checks that the member may edit, delete or assign role.
+func (r *rank) canManage(role *discordgo.Role) error {
+	if r.owner {
+		return nil
+	}
+	if !r.isAdmin() && r.permissions&discordgo.PermissionManageRoles == 0 {
+		return fmt.Errorf("%s lacks the manage_roles permission", r.who)
+	}
+	if r.top == nil || role.Position >= r.top.Position {
+		return fmt.Errorf("role %s is not below %s's highest role", role.Name, r.who)
+	}
+	return nil
+}
+
+// canGrant checks that the member holds every permission in permissions,
+// if set, and so may give them to a role.
+func (r *rank) canGrant(permissions *int64) error {
+	if !r.isAdmin() && r.permissions&discordgo.PermissionManageRoles == 0 {
+		return fmt.Errorf("%s lacks the manage_roles permission", r.who)
+	}
+	if permissions == nil || r.isAdmin() {
+		return nil
+	}
+	if missing := *permissions &^ r.permissions; missing != 0 {
+		return fmt.Errorf("%s cannot grant permissions it does not have: %s",
+			r.who, strings.Join(PermissionNames(missing), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// discordEpoch is the start of Discord's snowflake clock, in milliseconds
+// since the Unix epoch.
+const discordEpoch = 1420070400000
+
+// SnowflakeFromTime returns the smallest snowflake ID that could have been
+// created at t, for use as a message cursor.
+func SnowflakeFromTime(t time.Time) string {
+	ms := t.UnixMilli() - discordEpoch
+	if ms < 0 {
+		ms = 0
+	}
+	return strconv.FormatUint(uint64(ms)<<22, 10)
+}
+
+// HasMessageHistory reports whether channel holds messages that can be
+// read back. Forum and media channels hold none themselves; their posts
+// are threads.
+func HasMessageHistory(channel *discordgo.Channel) bool {
+	switch channel.Type {
+	case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildVoice:
+		return true
+	}
+	return channel.IsThread()
+}
+
+// SearchMessages returns up to filter.Limit messages matching filter,
+// newest first. Each channel's history is walked backwards from
+// filter.Before until filter.After, the channel's matches reach the limit,
+// or the client's maximum history has been scanned.
+func (c *Client) SearchMessages(ctx context.Context, filter MessageFilter) ([]*discordgo.Message, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	channelIDs := filter.ChannelIDs
+	if filter.ChannelID != "" {
+		channelIDs = append([]string{filter.ChannelID}, channelIDs...)
+	}
+
+	// A guild-wide search skips channels the bot cannot read rather than
+	// failing outright.
+	guildWide := len(channelIDs) == 0
+	if guildWide {
+		if filter.GuildID == "" {
+			return nil, fmt.Errorf("a channel or guild to search is required")
+		}
+
+		channels, err := c.GetGuildChannels(ctx, filter.GuildID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list guild channels: %w", err)
+		}
+		for _, channel := range channels {
+			if HasMessageHistory(channel) {
+				channelIDs = append(channelIDs, channel.ID)
+			}
+		}
+
+		// Threads, including forum posts, are not among the guild's
+		// channels. Active ones are listed separately; archived ones
+		// are left out, as listing them takes a request per channel.
+		active, err := c.session.GuildThreadsActive(filter.GuildID, discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list active threads: %w", err)
+		}
+		for _, thread := range active.Threads {
+			channelIDs = append(channelIDs, thread.ID)
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"channels": len(channelIDs),
+		"guild_id": filter.GuildID,
+		"limit":    limit,
+	}).Info("Searching messages")
+
+	var matches []*discordgo.Message
+	for _, channelID := range channelIDs {
+		found, err := c.searchChannel(ctx, channelID, filter, limit)
+		if err != nil {
+			if guildWide && isForbidden(err) {
+				c.logger.WithField("channel_id", channelID).Debug("Skipping unreadable channel")
+				continue
+			}
+			return nil, fmt.Errorf("failed to search channel %s: %w", channelID, err)
+		}
+		matches = append(matches, found...)
+	}
+
+	sortNewestFirst(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// searchChannel walks one channel's history backwards, collecting up to
+// limit matches.
+func (c *Client) searchChannel(ctx context.Context, channelID string, filter MessageFilter, limit int) ([]*discordgo.Message, error) {
+	var cursor string
+	if filter.Before != nil {
+		cursor = SnowflakeFromTime(*filter.Before)
+	}
+
+	var found []*discordgo.Message
+	for scanned := 0; scanned < c.maxHistory; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pageSize := c.maxHistory - scanned
+		if pageSize > messagePageSize {
+			pageSize = messagePageSize
+		}
+
+		page, err := c.session.ChannelMessages(channelID, pageSize, cursor, "", "", discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		sortNewestFirst(page)
+
+		for _, msg := range page {
+			if filter.After != nil && msg.Timestamp.Before(*filter.After) {
+				return found, nil
+			}
+			if c.matchesFilter(msg, filter) {
+				found = append(found, msg)
+				if len(found) == limit {
+					return found, nil
+				}
+			}
+		}
+
+		if len(page) < pageSize {
+			break
+		}
+		scanned += len(page)
+		cursor = page[len(page)-1].ID
+	}
+	return found, nil
+}
+
+func isForbidden(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil &&
+		(restErr.Response.StatusCode == http.StatusForbidden || restErr.Response.StatusCode == http.StatusNotFound)
+}
@@ -0,0 +1,141 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// maxArchivedThreads is the most archived threads Discord returns per
+// request.
+const maxArchivedThreads = 100
+
+// ThreadOptions describes a new thread.
+type ThreadOptions struct {
+	Name string
+
+	// MessageID starts the thread from an existing message. Otherwise a
+	// standalone thread is created, private if Private is set.
+	MessageID string
+	Private   bool
+
+	// AutoArchiveMinutes is one of 60, 1440, 4320 or 10080, or 0 for the
+	// channel's default.
+	AutoArchiveMinutes int
+}
+
+// CreateThread starts a thread in channelID.
+func (c *Client) CreateThread(ctx context.Context, channelID string, opts ThreadOptions) (*discordgo.Channel, error) {
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": opts.MessageID,
+		"name":       opts.Name,
+		"private":    opts.Private,
+	}).Info("Creating thread")
+
+	start := &discordgo.ThreadStart{
+		Name:                opts.Name,
+		AutoArchiveDuration: opts.AutoArchiveMinutes,
+	}
+	if opts.MessageID != "" {
+		return c.session.MessageThreadStartComplex(channelID, opts.MessageID, start, discordgo.WithContext(ctx))
+	}
+
+	start.Type = discordgo.ChannelTypeGuildPublicThread
+	if opts.Private {
+		start.Type = discordgo.ChannelTypeGuildPrivateThread
+	}
+	return c.session.ThreadStartComplex(channelID, start, discordgo.WithContext(ctx))
+}
+
+// GetActiveThreads returns the active threads whose parent is channelID.
+// Discord only lists active threads per guild, so the guild's list is
+// filtered.
+func (c *Client) GetActiveThreads(ctx context.Context, channelID string) ([]*discordgo.Channel, error) {
+	channel, err := c.GetChannelInfo(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.GuildID == "" {
+		return nil, fmt.Errorf("channel %s is not in a guild", channelID)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"guild_id":   channel.GuildID,
+		"channel_id": channelID,
+	}).Info("Fetching active threads")
+	list, err := c.session.GuildThreadsActive(channel.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var threads []*discordgo.Channel
+	for _, thread := range list.Threads {
+		if thread.ParentID == channelID {
+			threads = append(threads, thread)
+		}
+	}
+	return threads, nil
+}
+
+// GetArchivedThreads returns up to limit archived threads in channelID that
+// were archived before the given time (or most recently if before is nil),
+// and whether there are more. Private threads need the Manage Threads
+// permission.
+func (c *Client) GetArchivedThreads(ctx context.Context, channelID string, private bool, before *time.Time, limit int) ([]*discordgo.Channel, bool, error) {
+	if limit <= 0 || limit > maxArchivedThreads {
+		limit = maxArchivedThreads
+	}
+	c.logger.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"private":    private,
+		"limit":      limit,
+	}).Info("Fetching archived threads")
+
+	var list *discordgo.ThreadsList
+	var err error
+	if private {
+		list, err = c.session.ThreadsPrivateArchived(channelID, before, limit, discordgo.WithContext(ctx))
+	} else {
+		list, err = c.session.ThreadsArchived(channelID, before, limit, discordgo.WithContext(ctx))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return list.Threads, list.HasMore, nil
+}
+
+func (c *Client) JoinThread(ctx context.Context, threadID string) error {
+	c.logger.WithFields(logrus.Fields{
+		"thread_id": threadID,
+	}).Info("Joining thread")
+	return c.session.ThreadJoin(threadID, discordgo.WithContext(ctx))
+}
+
+func (c *Client) LeaveThread(ctx context.Context, threadID string) error {
+	c.logger.WithFields(logrus.Fields{
+		"thread_id": threadID,
+	}).Info("Leaving thread")
+	return c.session.ThreadLeave(threadID, discordgo.WithContext(ctx))
+}
+
+// ArchiveThread archives or reopens a thread. locked, if set, also locks or
+// unlocks it; locked threads can only be reopened by moderators.
+func (c *Client) ArchiveThread(ctx context.Context, threadID string, archived bool, locked *bool) (*discordgo.Channel, error) {
+	fields := logrus.Fields{
+		"thread_id": threadID,
+		"archived":  archived,
+	}
+	if locked != nil {
+		fields["locked"] = *locked
+	}
+	c.logger.WithFields(fields).Info("Updating thread")
+
+	return c.session.ChannelEditComplex(threadID, &discordgo.ChannelEdit{
+		Archived: &archived,
+		Locked:   locked,
+	}, discordgo.WithContext(ctx))
+}
@@ -0,0 +1,292 @@
+// Package index keeps a local full-text index of Discord messages so they
+// can be searched without calling the Discord API.
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	messagesBucket = []byte("messages")
+	postingsBucket = []byte("postings")
+	metaBucket     = []byte("meta")
+
+	docsKey   = []byte("docs")
+	lengthKey = []byte("length")
+)
+
+// Message is an indexed message.
+type Message struct {
+	ID        string    `json:"id"`
+	ChannelID string    `json:"channel_id"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	AuthorID  string    `json:"author_id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	Length    int       `json:"length"`
+}
+
+// Index is a full-text message index stored in a bbolt database. Postings
+// are keyed by term and message ID, so the messages containing a term can
+// be read with a single prefix scan.
+type Index struct {
+	db       *bolt.DB
+	logger   *logrus.Logger
+	channels map[string]bool
+}
+
+// Open opens or creates the index database at path. If channels is not
+// empty, only messages from those channels are indexed from gateway
+// events.
+func Open(path string, channels []string, logger *logrus.Logger) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open message index: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{messagesBucket, postingsBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize message index: %w", err)
+	}
+
+	idx := &Index{
+		db:       db,
+		logger:   logger,
+		channels: make(map[string]bool),
+	}
+	for _, id := range channels {
+		idx.channels[id] = true
+	}
+	return idx, nil
+}
+
+// Close closes the index database.
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+// Indexes reports whether messages from channelID are indexed.
+func (idx *Index) Indexes(channelID string) bool {
+	return len(idx.channels) == 0 || idx.channels[channelID]
+}
+
+// HandleEvent keeps the index in step with message events from the
+// gateway.
+func (idx *Index) HandleEvent(event discord.MessageEvent) {
+	if !idx.Indexes(event.ChannelID) {
+		return
+	}
+
+	var err error
+	switch event.Type {
+	case discord.MessageCreated, discord.MessageUpdated:
+		if event.Message != nil {
+			err = idx.Add(event.Message)
+		}
+	case discord.MessageDeleted:
+		err = idx.Delete(event.MessageIDs...)
+	}
+	if err != nil {
+		idx.logger.WithError(err).WithField("channel_id", event.ChannelID).Error("Failed to update message index")
+	}
+}
+
+// Add indexes messages, replacing any earlier version of them. Edits that
+// arrive without an author keep the author already stored.
+func (idx *Index) Add(messages ...*discordgo.Message) error {
+	return idx.db.Update(func(tx *bolt.Tx) error {
+		for _, msg := range messages {
+			if err := idx.add(tx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (idx *Index) add(tx *bolt.Tx, msg *discordgo.Message) error {
+	key, err := messageKey(msg.ID)
+	if err != nil {
+		return err
+	}
+
+	doc := Message{
+		ID:        msg.ID,
+		ChannelID: msg.ChannelID,
+		GuildID:   msg.GuildID,
+		Content:   msg.Content,
+		Timestamp: msg.Timestamp,
+	}
+	if msg.Author != nil {
+		doc.AuthorID = msg.Author.ID
+		doc.Author = msg.Author.Username
+	}
+
+	old, err := getMessage(tx, key)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		if doc.AuthorID == "" {
+			doc.AuthorID, doc.Author = old.AuthorID, old.Author
+		}
+		if doc.Timestamp.IsZero() {
+			doc.Timestamp = old.Timestamp
+		}
+		if doc.GuildID == "" {
+			doc.GuildID = old.GuildID
+		}
+		if err := removeDocument(tx, key, old); err != nil {
+			return err
+		}
+	}
+
+	terms := termFrequencies(doc.Content)
+	doc.Length = 0
+	for _, tf := range terms {
+		doc.Length += tf
+	}
+
+	// Each posting holds the term's frequency and the message's length, so
+	// ranking never has to read the message itself
+	postings := tx.Bucket(postingsBucket)
+	for term, tf := range terms {
+		value := binary.AppendUvarint(binary.AppendUvarint(nil, uint64(tf)), uint64(doc.Length))
+		if err := postings.Put(postingKey(term, key), value); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(messagesBucket).Put(key, data); err != nil {
+		return err
+	}
+	return adjustStats(tx, 1, doc.Length)
+}
+
+// Delete removes messages from the index. Unknown IDs are ignored.
+func (idx *Index) Delete(ids ...string) error {
+	return idx.db.Update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			key, err := messageKey(id)
+			if err != nil {
+				return err
+			}
+			old, err := getMessage(tx, key)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				continue
+			}
+			if err := removeDocument(tx, key, old); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Count returns the number of indexed messages.
+func (idx *Index) Count() (int, error) {
+	var count int
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		docs, _ := stats(tx)
+		count = int(docs)
+		return nil
+	})
+	return count, err
+}
+
+func removeDocument(tx *bolt.Tx, key []byte, doc *Message) error {
+	postings := tx.Bucket(postingsBucket)
+	for term := range termFrequencies(doc.Content) {
+		if err := postings.Delete(postingKey(term, key)); err != nil {
+			return err
+		}
+	}
+	if err := tx.Bucket(messagesBucket).Delete(key); err != nil {
+		return err
+	}
+	return adjustStats(tx, -1, -doc.Length)
+}
+
+func getMessage(tx *bolt.Tx, key []byte) (*Message, error) {
+	data := tx.Bucket(messagesBucket).Get(key)
+	if data == nil {
+		return nil, nil
+	}
+
+	var doc Message
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("corrupt index entry %x: %w", key, err)
+	}
+	return &doc, nil
+}
+
+// stats returns the number of indexed messages and their total length in
+// terms, used for ranking.
+func stats(tx *bolt.Tx) (uint64, uint64) {
+	meta := tx.Bucket(metaBucket)
+	return readUint(meta.Get(docsKey)), readUint(meta.Get(lengthKey))
+}
+
+func adjustStats(tx *bolt.Tx, docs, length int) error {
+	meta := tx.Bucket(metaBucket)
+	d, l := stats(tx)
+	if err := meta.Put(docsKey, binary.BigEndian.AppendUint64(nil, uint64(int64(d)+int64(docs)))); err != nil {
+		return err
+	}
+	return meta.Put(lengthKey, binary.BigEndian.AppendUint64(nil, uint64(int64(l)+int64(length))))
+}
+
+func readUint(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// messageKey encodes a snowflake ID as 8 big-endian bytes, so keys sort in
+// creation order.
+func messageKey(id string) ([]byte, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message ID %q", id)
+	}
+	return binary.BigEndian.AppendUint64(nil, n), nil
+}
+
+func postingKey(term string, key []byte) []byte {
+	k := make([]byte, 0, len(term)+1+len(key))
+	k = append(k, term...)
+	k = append(k, 0)
+	return append(k, key...)
+}
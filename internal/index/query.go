@@ -0,0 +1,424 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// maxTermLength, in runes, truncates very long tokens such as pasted
+	// hashes.
+	maxTermLength = 64
+
+	// maxPrefixExpansion bounds how many terms a prefix query matches.
+	maxPrefixExpansion = 256
+
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SearchOptions restricts a search to part of the index.
+type SearchOptions struct {
+	ChannelIDs []string
+	GuildID    string
+	AuthorID   string
+	Limit      int
+}
+
+// Result is a message matching a search, with its relevance score.
+type Result struct {
+	Message Message `json:"message"`
+	Score   float64 `json:"score"`
+}
+
+// Search runs query against the index, returning the best matching
+// messages first.
+//
+// Words must all appear in a message. OR separates alternatives, a leading
+// "-" or NOT excludes a word, "quoted phrases" must appear verbatim and a
+// trailing "*" matches any word with that prefix. Results are ranked with
+// BM25, newest first among equal scores.
+func (idx *Index) Search(query string, opts SearchOptions) ([]Result, error) {
+	clauses, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 25
+	}
+	channels := make(map[string]bool)
+	for _, id := range opts.ChannelIDs {
+		channels[id] = true
+	}
+
+	var results []Result
+	err = idx.db.View(func(tx *bolt.Tx) error {
+		docs, length := stats(tx)
+		if docs == 0 {
+			return nil
+		}
+		s := &searcher{
+			tx:       tx,
+			docs:     float64(docs),
+			avgLen:   float64(length) / float64(docs),
+			postings: make(map[string]map[string]posting),
+			lengths:  make(map[string]float64),
+		}
+
+		scores := make(map[string]float64)
+		for _, c := range clauses {
+			matches, err := s.evaluate(c)
+			if err != nil {
+				return err
+			}
+			for key, score := range matches {
+				scores[key] += score
+			}
+		}
+
+		// Rank before reading any message, so only those returned, and
+		// any the filters skip on the way, are decoded. Keys sort in
+		// creation order, so the greater key is the newer message.
+		keys := make([]string, 0, len(scores))
+		for key := range scores {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if scores[keys[i]] != scores[keys[j]] {
+				return scores[keys[i]] > scores[keys[j]]
+			}
+			return keys[i] > keys[j]
+		})
+
+		for _, key := range keys {
+			if len(results) == limit {
+				break
+			}
+			doc, err := getMessage(tx, []byte(key))
+			if err != nil {
+				return err
+			}
+			if doc == nil ||
+				(len(channels) > 0 && !channels[doc.ChannelID]) ||
+				(opts.GuildID != "" && doc.GuildID != opts.GuildID) ||
+				(opts.AuthorID != "" && doc.AuthorID != opts.AuthorID) {
+				continue
+			}
+			results = append(results, Result{Message: *doc, Score: scores[key]})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// clause is a set of conditions that must all hold; a query matches a
+// message if any of its clauses does.
+type clause struct {
+	include []matcher
+	exclude []matcher
+}
+
+// matcher is a single word, prefix or phrase.
+type matcher struct {
+	terms  []string
+	prefix bool
+	phrase string
+}
+
+func parseQuery(query string) ([]clause, error) {
+	var clauses []clause
+	var current clause
+	negate := false
+
+	flush := func() error {
+		if len(current.include) == 0 && len(current.exclude) == 0 {
+			return nil
+		}
+		if len(current.include) == 0 {
+			return fmt.Errorf("each part of a query needs at least one word to match, not only excluded ones")
+		}
+		clauses = append(clauses, current)
+		current = clause{}
+		return nil
+	}
+
+	for _, token := range splitQuery(query) {
+		switch {
+		case token == "OR":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		case token == "AND":
+			continue
+		case token == "NOT":
+			negate = true
+			continue
+		}
+
+		exclude := negate
+		negate = false
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			exclude = true
+			token = token[1:]
+		}
+
+		var m matcher
+		if strings.HasPrefix(token, `"`) {
+			phrase := strings.Trim(token, `"`)
+			m = matcher{terms: tokenize(phrase), phrase: strings.ToLower(phrase)}
+			if len(m.terms) < 2 {
+				m.phrase = ""
+			}
+		} else {
+			m.prefix = strings.HasSuffix(token, "*")
+			m.terms = tokenize(strings.TrimSuffix(token, "*"))
+			if len(m.terms) != 1 {
+				m.prefix = false
+			}
+		}
+		if len(m.terms) == 0 {
+			continue
+		}
+
+		if exclude {
+			current.exclude = append(current.exclude, m)
+		} else {
+			current.include = append(current.include, m)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("query has no words to search for")
+	}
+	return clauses, nil
+}
+
+// splitQuery splits a query on whitespace, keeping quoted phrases
+// (optionally negated) together.
+func splitQuery(query string) []string {
+	var tokens []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if b.Len() > 0 {
+				tokens = append(tokens, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		tokens = append(tokens, b.String())
+	}
+	return tokens
+}
+
+// tokenize splits text into lowercase words.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		if utf8.RuneCountInString(w) > maxTermLength {
+			words[i] = string([]rune(w)[:maxTermLength])
+		}
+	}
+	return words
+}
+
+func termFrequencies(text string) map[string]int {
+	terms := make(map[string]int)
+	for _, term := range tokenize(text) {
+		terms[term]++
+	}
+	return terms
+}
+
+// searcher evaluates clauses within one read transaction.
+type searcher struct {
+	tx       *bolt.Tx
+	docs     float64
+	avgLen   float64
+	postings map[string]map[string]posting
+
+	// lengths caches the lengths of messages whose postings predate
+	// lengths being stored in them.
+	lengths map[string]float64
+}
+
+// posting is how often a term occurs in a message, and the message's
+// length in terms.
+type posting struct {
+	tf     int
+	length int
+}
+
+// evaluate returns the messages matching c and their scores.
+func (s *searcher) evaluate(c clause) (map[string]float64, error) {
+	var scores map[string]float64
+	for _, m := range c.include {
+		matches, err := s.match(m)
+		if err != nil {
+			return nil, err
+		}
+
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for key := range scores {
+			if score, ok := matches[key]; ok {
+				scores[key] += score
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	for _, m := range c.exclude {
+		matches, err := s.match(m)
+		if err != nil {
+			return nil, err
+		}
+		for key := range matches {
+			delete(scores, key)
+		}
+	}
+	return scores, nil
+}
+
+// match returns the messages containing every term of m, scored by BM25.
+func (s *searcher) match(m matcher) (map[string]float64, error) {
+	var scores map[string]float64
+	for _, term := range m.terms {
+		termScores := make(map[string]float64)
+
+		terms := []string{term}
+		if m.prefix {
+			terms = s.expandPrefix(term)
+		}
+		for _, t := range terms {
+			postings := s.postingList(t)
+			idf := math.Log(1 + (s.docs-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for key, p := range postings {
+				termScores[key] += idf * s.termWeight(key, p)
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for key := range scores {
+			if score, ok := termScores[key]; ok {
+				scores[key] += score
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	if m.phrase != "" {
+		for key := range scores {
+			doc, err := getMessage(s.tx, []byte(key))
+			if err != nil {
+				return nil, err
+			}
+			if doc == nil || !strings.Contains(strings.ToLower(doc.Content), m.phrase) {
+				delete(scores, key)
+			}
+		}
+	}
+	return scores, nil
+}
+
+// termWeight is the BM25 term-frequency component for a message.
+func (s *searcher) termWeight(key string, p posting) float64 {
+	length := float64(p.length)
+	if p.length == 0 {
+		length = s.docLength(key)
+	}
+	f := float64(p.tf)
+	return f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*length/s.avgLen))
+}
+
+// docLength reads a message's length from the message itself, for
+// postings written before they carried it.
+func (s *searcher) docLength(key string) float64 {
+	if length, ok := s.lengths[key]; ok {
+		return length
+	}
+	length := s.avgLen
+	if doc, err := getMessage(s.tx, []byte(key)); err == nil && doc != nil {
+		length = float64(doc.Length)
+	}
+	s.lengths[key] = length
+	return length
+}
+
+// postingList returns the messages containing term, how often and their
+// lengths.
+func (s *searcher) postingList(term string) map[string]posting {
+	if postings, ok := s.postings[term]; ok {
+		return postings
+	}
+
+	postings := make(map[string]posting)
+	prefix := append([]byte(term), 0)
+	c := s.tx.Bucket(postingsBucket).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		tf, n := binary.Uvarint(v)
+		p := posting{tf: int(tf)}
+		if n > 0 && n < len(v) {
+			length, _ := binary.Uvarint(v[n:])
+			p.length = int(length)
+		}
+		postings[string(k[len(prefix):])] = p
+	}
+	s.postings[term] = postings
+	return postings
+}
+
+// expandPrefix returns the indexed terms starting with prefix.
+func (s *searcher) expandPrefix(prefix string) []string {
+	var terms []string
+	c := s.tx.Bucket(postingsBucket).Cursor()
+	for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); {
+		end := bytes.IndexByte(k, 0)
+		if end < 0 {
+			break
+		}
+		term := string(k[:end])
+		terms = append(terms, term)
+		if len(terms) == maxPrefixExpansion {
+			break
+		}
+		// Skip the rest of this term's postings
+		k, _ = c.Seek(append([]byte(term), 1))
+	}
+	return terms
+}
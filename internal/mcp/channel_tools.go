@@ -0,0 +1,433 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelTools list and administer a guild's channels. Changes record the
+// optional reason in the guild's audit log.
+var channelTools = []Tool{
+	{
+		Name:        "list_channels",
+		Description: "List a guild's channels grouped by category, with their types and permission overwrites",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"type": {
+					"type": "string",
+					"description": "Only channels of this type, e.g. text, voice, category or forum"
+				}
+			}
+		}`),
+		OutputSchema: channelListOutputSchema,
+	},
+	{
+		Name:        "create_channel",
+		Description: "Create a channel in a guild",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"name": {
+					"type": "string",
+					"description": "The channel's name"
+				},
+				"type": {
+					"type": "string",
+					"enum": ["text", "voice", "category", "announcement", "stage", "forum", "media"],
+					"default": "text"
+				},
+				"topic": {
+					"type": "string",
+					"description": "The channel's topic"
+				},
+				"parent_id": {
+					"type": "string",
+					"description": "The ID of the category to create the channel in"
+				},
+				"nsfw": {
+					"type": "boolean",
+					"default": false
+				},
+				"slowmode_seconds": {
+					"type": "number",
+					"description": "Seconds members must wait between messages (0-21600)"
+				},
+				"position": {
+					"type": "number",
+					"description": "The channel's position in the channel list"
+				},
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["name"]
+		}`),
+		OutputSchema: channelOutputSchema,
+	},
+	{
+		Name:        "edit_channel",
+		Description: "Change a channel's name, topic, slowmode, NSFW flag or category",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel"
+				},
+				"name": {
+					"type": "string",
+					"description": "The new name"
+				},
+				"topic": {
+					"type": "string",
+					"description": "The new topic, or an empty string to clear it"
+				},
+				"slowmode_seconds": {
+					"type": "number",
+					"description": "Seconds members must wait between messages (0-21600), 0 to disable"
+				},
+				"nsfw": {
+					"type": "boolean"
+				},
+				"parent_id": {
+					"type": "string",
+					"description": "The ID of the category to move the channel to, or an empty string to remove it from its category"
+				},
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["channel_id"]
+		}`),
+		OutputSchema: channelOutputSchema,
+	},
+	{
+		Name:        "delete_channel",
+		Description: "Delete a channel. This cannot be undone",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel"
+				},
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["channel_id"]
+		}`),
+		OutputSchema: channelActionOutputSchema,
+	},
+	{
+		Name:        "reorder_channels",
+		Description: "Move channels to new positions, optionally into another category",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"channels": {
+					"type": "array",
+					"minItems": 1,
+					"items": {
+						"type": "object",
+						"properties": {
+							"id": {"type": "string"},
+							"position": {"type": "number"},
+							"parent_id": {"type": "string", "description": "Move the channel into this category"}
+						},
+						"required": ["id", "position"]
+					}
+				},
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["channels"]
+		}`),
+		OutputSchema: channelActionOutputSchema,
+	},
+}
+
+// maxSlowmode is the longest slowmode Discord allows, in seconds.
+const maxSlowmode = 21600
+
+func (s *Server) handleListChannels(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	typeName, _ := args["type"].(string)
+
+	channels, err := s.discordClient.GetGuildChannels(ctx, guildID)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to get guild channels: %w", err)
+	}
+	sortChannels(channels)
+
+	result := ChannelListResult{
+		GuildID:  guildID,
+		Channels: make([]DiscordChannel, 0, len(channels)),
+	}
+
+	var b strings.Builder
+	for _, channel := range channels {
+		c := toDiscordChannel(channel)
+		if typeName != "" && c.TypeName != typeName {
+			continue
+		}
+		result.Channels = append(result.Channels, c)
+
+		if c.ParentID != "" {
+			b.WriteString("  ")
+		}
+		fmt.Fprintf(&b, "%s (%s, %s)", c.Name, c.ID, c.TypeName)
+		if n := len(c.PermissionOverwrites); n > 0 {
+			fmt.Fprintf(&b, ", %d overwrites", n)
+		}
+		b.WriteString("\n")
+	}
+	result.Count = len(result.Channels)
+
+	header := fmt.Sprintf("Found %d channels in guild %s:\n", result.Count, guildID)
+	return structuredResult(header+b.String(), result), nil
+}
+
+func (s *Server) handleCreateChannel(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	data := discordgo.GuildChannelCreateData{}
+	data.Name, _ = args["name"].(string)
+	if strings.TrimSpace(data.Name) == "" {
+		return CallToolResult{}, fmt.Errorf("name is required")
+	}
+
+	typeName := "text"
+	if t, ok := args["type"].(string); ok && t != "" {
+		typeName = t
+	}
+	if data.Type, err = discord.ParseChannelType(typeName); err != nil {
+		return CallToolResult{}, err
+	}
+
+	data.Topic, _ = args["topic"].(string)
+	data.ParentID, _ = args["parent_id"].(string)
+	data.NSFW, _ = args["nsfw"].(bool)
+	if data.RateLimitPerUser, err = slowmodeArg(args); err != nil {
+		return CallToolResult{}, err
+	}
+	if p, ok := args["position"].(float64); ok {
+		data.Position = int(p)
+	}
+	reason, _ := args["reason"].(string)
+
+	channel, err := s.discordClient.CreateChannel(ctx, guildID, data, reason)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	info := toDiscordChannel(channel)
+	return structuredResult(
+		fmt.Sprintf("Created %s channel %s. Channel ID: %s", info.TypeName, info.Name, info.ID),
+		info,
+	), nil
+}
+
+func (s *Server) handleEditChannel(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, ok := args["channel_id"].(string)
+	if !ok || channelID == "" {
+		return CallToolResult{}, fmt.Errorf("channel_id is required")
+	}
+
+	settings := discord.ChannelSettings{}
+	if name, ok := args["name"].(string); ok {
+		if strings.TrimSpace(name) == "" {
+			return CallToolResult{}, fmt.Errorf("name cannot be empty")
+		}
+		settings.Name = &name
+	}
+	if topic, ok := args["topic"].(string); ok {
+		settings.Topic = &topic
+	}
+	if nsfw, ok := args["nsfw"].(bool); ok {
+		settings.NSFW = &nsfw
+	}
+	if parentID, ok := args["parent_id"].(string); ok {
+		settings.ParentID = &parentID
+	}
+	if _, ok := args["slowmode_seconds"]; ok {
+		slowmode, err := slowmodeArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		settings.Slowmode = &slowmode
+	}
+	if settings == (discord.ChannelSettings{}) {
+		return CallToolResult{}, fmt.Errorf("nothing to change: pass name, topic, slowmode_seconds, nsfw or parent_id")
+	}
+	reason, _ := args["reason"].(string)
+
+	channel, err := s.discordClient.EditChannel(ctx, channelID, settings, reason)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to edit channel: %w", err)
+	}
+
+	info := toDiscordChannel(channel)
+	return structuredResult(fmt.Sprintf("Channel %s (%s) updated", info.Name, info.ID), info), nil
+}
+
+func (s *Server) handleDeleteChannel(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, ok := args["channel_id"].(string)
+	if !ok || channelID == "" {
+		return CallToolResult{}, fmt.Errorf("channel_id is required")
+	}
+	reason, _ := args["reason"].(string)
+
+	channel, err := s.discordClient.DeleteChannel(ctx, channelID, reason)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to delete channel: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Channel %s (%s) deleted", channel.Name, channelID),
+		ChannelActionResult{
+			Action:     "delete_channel",
+			GuildID:    channel.GuildID,
+			ChannelIDs: []string{channelID},
+			Reason:     reason,
+		},
+	), nil
+}
+
+func (s *Server) handleReorderChannels(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	items, _ := args["channels"].([]interface{})
+	if len(items) == 0 {
+		return CallToolResult{}, fmt.Errorf("channels is required")
+	}
+	positions := make([]discord.ChannelPosition, 0, len(items))
+	ids := make([]string, 0, len(items))
+	for i, item := range items {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			return CallToolResult{}, fmt.Errorf("channels[%d] must be an object", i)
+		}
+		id, _ := c["id"].(string)
+		if id == "" {
+			return CallToolResult{}, fmt.Errorf("channels[%d].id is required", i)
+		}
+		position, ok := c["position"].(float64)
+		if !ok {
+			return CallToolResult{}, fmt.Errorf("channels[%d].position is required", i)
+		}
+		parentID, _ := c["parent_id"].(string)
+
+		positions = append(positions, discord.ChannelPosition{ID: id, Position: int(position), ParentID: parentID})
+		ids = append(ids, id)
+	}
+	reason, _ := args["reason"].(string)
+
+	if err := s.discordClient.ReorderChannels(ctx, guildID, positions, reason); err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to reorder channels: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Moved %d channels in guild %s", len(positions), guildID),
+		ChannelActionResult{Action: "reorder_channels", GuildID: guildID, ChannelIDs: ids, Reason: reason},
+	), nil
+}
+
+// guildArg returns the guild_id argument, falling back to the configured
+// guild.
+func (s *Server) guildArg(args map[string]interface{}) (string, error) {
+	guildID, _ := args["guild_id"].(string)
+	if guildID == "" {
+		guildID = s.config.Discord.GuildID
+	}
+	if guildID == "" {
+		return "", fmt.Errorf("guild_id is required")
+	}
+	return guildID, nil
+}
+
+// slowmodeArg returns the slowmode_seconds argument, or 0 if it is not set.
+func slowmodeArg(args map[string]interface{}) (int, error) {
+	s, ok := args["slowmode_seconds"].(float64)
+	if !ok {
+		return 0, nil
+	}
+	if s < 0 || s > maxSlowmode {
+		return 0, fmt.Errorf("slowmode_seconds must be between 0 and %d", maxSlowmode)
+	}
+	return int(s), nil
+}
+
+// sortChannels orders channels as Discord shows them: channels outside any
+// category first, then each category followed by its channels.
+func sortChannels(channels []*discordgo.Channel) {
+	categories := make(map[string]*discordgo.Channel)
+	for _, c := range channels {
+		if c.Type == discordgo.ChannelTypeGuildCategory {
+			categories[c.ID] = c
+		}
+	}
+
+	// group returns the category a channel is listed under, or nil
+	group := func(c *discordgo.Channel) *discordgo.Channel {
+		if c.Type == discordgo.ChannelTypeGuildCategory {
+			return c
+		}
+		return categories[c.ParentID]
+	}
+
+	sort.SliceStable(channels, func(i, j int) bool {
+		a, b := channels[i], channels[j]
+		ga, gb := group(a), group(b)
+		if ga != gb {
+			if ga == nil || gb == nil {
+				return ga == nil
+			}
+			if ga.Position != gb.Position {
+				return ga.Position < gb.Position
+			}
+			return ga.ID < gb.ID
+		}
+		// Within a group the category itself comes first
+		if (a.Type == discordgo.ChannelTypeGuildCategory) != (b.Type == discordgo.ChannelTypeGuildCategory) {
+			return a.Type == discordgo.ChannelTypeGuildCategory
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+}
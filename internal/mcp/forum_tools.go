@@ -0,0 +1,365 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// forumTools read and write posts in forum channels. A post is a thread,
+// so its replies are read with get_messages and it is archived with
+// archive_thread.
+var forumTools = []Tool{
+	{
+		Name:        "list_forum_posts",
+		Description: "List the posts in a forum channel with their tags",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the forum channel"
+				},
+				"tags": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Only posts with at least one of these tags, by name or ID"
+				},
+				"archived": {
+					"type": "boolean",
+					"description": "List archived posts instead of active ones",
+					"default": false
+				},
+				"before": {
+					"type": "string",
+					"description": "With archived, only posts archived before this timestamp (ISO 8601), e.g. the cursor from a previous call"
+				},
+				"limit": {
+					"type": "number",
+					"description": "With archived, maximum number of posts to fetch (default: 50, max: 100)",
+					"default": 50
+				}
+			},
+			"required": ["channel_id"]
+		}`),
+		OutputSchema: threadListOutputSchema,
+	},
+	{
+		Name:        "create_forum_post",
+		Description: "Create a post in a forum channel",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the forum channel"
+				},
+				"title": {
+					"type": "string",
+					"description": "The post's title"
+				},
+				"content": {
+					"type": "string",
+					"description": "The body of the post"
+				},
+				"tags": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Tags to apply, by name or ID. Forums may require at least one"
+				},
+				"embeds": {
+					"type": "array",
+					"description": "Embeds, in the same form as send_message",
+					"maxItems": 10,
+					"items": {"type": "object"}
+				},
+				"attachments": {
+					"type": "array",
+					"description": "Files, in the same form as send_message",
+					"maxItems": 10,
+					"items": {"type": "object"}
+				},
+				"allowed_mentions": {
+					"type": "object",
+					"description": "Which mentions in the body may ping, as for send_message"
+				},
+				"auto_archive_minutes": {
+					"type": "number",
+					"enum": [60, 1440, 4320, 10080],
+					"description": "Archive the post after this many minutes without activity"
+				}
+			},
+			"required": ["channel_id", "title"]
+		}`),
+		OutputSchema: threadOutputSchema,
+	},
+	{
+		Name:        "manage_forum_tags",
+		Description: "Add, update or remove the tags a forum channel offers",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the forum channel"
+				},
+				"add": {
+					"type": "array",
+					"description": "Tags to add, or to update if a tag with the name exists",
+					"items": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string"},
+							"emoji": {"type": "string", "description": "A unicode emoji shown with the tag"},
+							"moderated": {"type": "boolean", "description": "Only moderators can apply the tag"}
+						},
+						"required": ["name"]
+					}
+				},
+				"remove": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Tags to remove, by name or ID"
+				}
+			},
+			"required": ["channel_id"]
+		}`),
+		OutputSchema: channelOutputSchema,
+	},
+}
+
+// getForum fetches channelID and checks it is a forum.
+func (s *Server) getForum(ctx context.Context, args map[string]interface{}) (*discordgo.Channel, error) {
+	channelID, ok := args["channel_id"].(string)
+	if !ok || channelID == "" {
+		return nil, fmt.Errorf("channel_id is required")
+	}
+
+	forum, err := s.discordClient.GetChannelInfo(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel info: %w", err)
+	}
+	if !discord.IsForum(forum) {
+		return nil, fmt.Errorf("channel %s is a %s channel, not a forum", channelID, discord.ChannelTypeName(forum.Type))
+	}
+	return forum, nil
+}
+
+func (s *Server) handleListForumPosts(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	forum, err := s.getForum(ctx, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	wanted := make(map[string]bool)
+	if refs := stringList(args["tags"]); len(refs) > 0 {
+		ids, err := discord.ResolveForumTags(forum, refs)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		for _, id := range ids {
+			wanted[id] = true
+		}
+	}
+
+	archived, _ := args["archived"].(bool)
+	var posts []*discordgo.Channel
+	var hasMore bool
+	if archived {
+		var before *time.Time
+		if b, ok := args["before"].(string); ok && b != "" {
+			t, err := time.Parse(time.RFC3339, b)
+			if err != nil {
+				return CallToolResult{}, fmt.Errorf("invalid before timestamp: %w", err)
+			}
+			before = &t
+		}
+
+		limit := 50
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		posts, hasMore, err = s.discordClient.GetArchivedThreads(ctx, forum.ID, false, before, limit)
+	} else {
+		posts, err = s.discordClient.GetActiveThreads(ctx, forum.ID)
+	}
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to list forum posts: %w", err)
+	}
+
+	result := ThreadListResult{
+		ChannelID: forum.ID,
+		Archived:  archived,
+		Threads:   make([]DiscordThread, 0, len(posts)),
+	}
+
+	var b strings.Builder
+	for _, post := range posts {
+		if len(wanted) > 0 && !hasAnyTag(post, wanted) {
+			continue
+		}
+		p := toForumPost(forum, post)
+		result.Threads = append(result.Threads, p)
+
+		fmt.Fprintf(&b, "%s (%s): %d messages", p.Name, p.ID, p.MessageCount)
+		if len(p.AppliedTags) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(p.AppliedTags, ", "))
+		}
+		b.WriteString("\n")
+	}
+	result.Count = len(result.Threads)
+
+	// The cursor follows the last post fetched, not the last one kept by
+	// the tag filter
+	if hasMore && len(posts) > 0 {
+		result.NextBefore = toDiscordThread(posts[len(posts)-1]).ArchivedAt
+		fmt.Fprintf(&b, "More posts are available; continue with before=%q.", result.NextBefore)
+	}
+
+	state := "active"
+	if archived {
+		state = "archived"
+	}
+	header := fmt.Sprintf("Found %d %s posts in forum %s:\n", result.Count, state, forum.Name)
+	return structuredResult(header+b.String(), result), nil
+}
+
+func (s *Server) handleCreateForumPost(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	forum, err := s.getForum(ctx, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	post := discord.ForumPostOptions{}
+	post.Title, _ = args["title"].(string)
+	if strings.TrimSpace(post.Title) == "" {
+		return CallToolResult{}, fmt.Errorf("title is required")
+	}
+
+	post.Message, err = s.buildMessageSend(forum.ID, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	// A post's starter message cannot reply to anything
+	post.Message.Reference = nil
+	post.Message.TTS = false
+
+	post.TagIDs, err = discord.ResolveForumTags(forum, stringList(args["tags"]))
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	if post.AutoArchiveMinutes, err = autoArchiveArg(args); err != nil {
+		return CallToolResult{}, err
+	}
+
+	thread, err := s.discordClient.CreateForumPost(ctx, forum.ID, post)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to create forum post: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Forum post %q created. Post ID: %s", thread.Name, thread.ID),
+		toForumPost(forum, thread),
+	), nil
+}
+
+func (s *Server) handleManageForumTags(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	forum, err := s.getForum(ctx, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	removed := stringList(args["remove"])
+	added, _ := args["add"].([]interface{})
+	if len(added) == 0 && len(removed) == 0 {
+		return CallToolResult{}, fmt.Errorf("add or remove is required")
+	}
+
+	tags := append([]discordgo.ForumTag(nil), forum.AvailableTags...)
+	for _, ref := range removed {
+		tag, ok := discord.FindForumTag(forum, ref)
+		if !ok {
+			return CallToolResult{}, fmt.Errorf("forum %s has no tag %q", forum.ID, ref)
+		}
+		for i := range tags {
+			if tags[i].ID == tag.ID {
+				tags = append(tags[:i], tags[i+1:]...)
+				break
+			}
+		}
+	}
+
+	for i, item := range added {
+		a, ok := item.(map[string]interface{})
+		if !ok {
+			return CallToolResult{}, fmt.Errorf("add[%d] must be an object", i)
+		}
+		name, _ := a["name"].(string)
+		if strings.TrimSpace(name) == "" {
+			return CallToolResult{}, fmt.Errorf("add[%d].name is required", i)
+		}
+
+		tag := discordgo.ForumTag{Name: name}
+		if existing, ok := discord.FindForumTag(&discordgo.Channel{AvailableTags: tags}, name); ok {
+			tag = existing
+		}
+		if emoji, ok := a["emoji"].(string); ok {
+			tag.EmojiName = emoji
+		}
+		if moderated, ok := a["moderated"].(bool); ok {
+			tag.Moderated = moderated
+		}
+
+		if tag.ID == "" {
+			tags = append(tags, tag)
+			continue
+		}
+		for j := range tags {
+			if tags[j].ID == tag.ID {
+				tags[j] = tag
+			}
+		}
+	}
+
+	updated, err := s.discordClient.SetForumTags(ctx, forum.ID, tags)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to update forum tags: %w", err)
+	}
+
+	info := toDiscordChannel(updated)
+	names := make([]string, 0, len(info.AvailableTags))
+	for _, tag := range info.AvailableTags {
+		names = append(names, tag.Name)
+	}
+	return structuredResult(
+		fmt.Sprintf("Forum %s now has %d tags: %s", info.Name, len(names), strings.Join(names, ", ")),
+		info,
+	), nil
+}
+
+// toForumPost converts a post in forum, naming its applied tags.
+func toForumPost(forum, post *discordgo.Channel) DiscordThread {
+	p := toDiscordThread(post)
+	for _, id := range post.AppliedTags {
+		if tag, ok := discord.FindForumTag(forum, id); ok {
+			p.AppliedTags = append(p.AppliedTags, tag.Name)
+		} else {
+			p.AppliedTags = append(p.AppliedTags, id)
+		}
+	}
+	return p
+}
+
+func hasAnyTag(post *discordgo.Channel, tagIDs map[string]bool) bool {
+	for _, id := range post.AppliedTags {
+		if tagIDs[id] {
+			return true
+		}
+	}
+	return false
+}
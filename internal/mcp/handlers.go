@@ -1,18 +1,54 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
 	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
 )
 
-func (s *Server) handleToolsList(request JSONRPCRequest) error {
+func (s *Server) handleToolsList(sess *session, w messageWriter, request JSONRPCRequest) error {
+	// Only advertise the tools the caller is allowed to invoke
+	claims := sess.caller()
+	structured := s.sessionProtocolVersion(sess) == structuredOutputVersion
+	tools := s.Tools()
+	allowed := make([]Tool, 0, len(tools))
+	for _, tool := range tools {
+		if claims != nil && canUseTool(claims, tool.Name) {
+			if !structured {
+				tool.OutputSchema = nil
+			}
+			allowed = append(allowed, tool)
+		}
+	}
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: ListToolsResult{
+			Tools: allowed,
+		},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+// Tools returns every tool the server offers, whichever of them a given
+// caller may use.
+func (s *Server) Tools() []Tool {
 	tools := []Tool{
 		{
 			Name:        "send_message",
-			Description: "Send a message to a Discord channel",
+			Description: "Send a message to a Discord channel, optionally as a reply and with embeds or attachments",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -22,30 +58,136 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 					},
 					"content": {
 						"type": "string",
-						"description": "The content of the message to send"
+						"description": "The content of the message to send. Required unless embeds or attachments are given"
+					},
+					"reply_to": {
+						"type": "string",
+						"description": "ID of a message in the channel to reply to"
+					},
+					"tts": {
+						"type": "boolean",
+						"description": "Send as a text-to-speech message",
+						"default": false
+					},
+					"embeds": {
+						"type": "array",
+						"description": "Up to 10 embeds",
+						"maxItems": 10,
+						"items": {
+							"type": "object",
+							"properties": {
+								"title": {"type": "string"},
+								"description": {"type": "string"},
+								"url": {"type": "string"},
+								"color": {
+									"type": ["number", "string"],
+									"description": "Color as a number or a \"#rrggbb\" string"
+								},
+								"footer": {"type": "string"},
+								"image_url": {"type": "string"},
+								"thumbnail_url": {"type": "string"},
+								"fields": {
+									"type": "array",
+									"items": {
+										"type": "object",
+										"properties": {
+											"name": {"type": "string"},
+											"value": {"type": "string"},
+											"inline": {"type": "boolean"}
+										},
+										"required": ["name", "value"]
+									}
+								}
+							}
+						}
+					},
+					"allowed_mentions": {
+						"type": "object",
+						"description": "Which mentions may ping. By default users and roles can be pinged but @everyone and @here cannot",
+						"properties": {
+							"parse": {
+								"type": "array",
+								"items": {"type": "string", "enum": ["users", "roles", "everyone"]},
+								"description": "Mention types to ping; \"everyone\" covers @everyone and @here"
+							},
+							"users": {
+								"type": "array",
+								"items": {"type": "string"},
+								"description": "User IDs that may be pinged"
+							},
+							"roles": {
+								"type": "array",
+								"items": {"type": "string"},
+								"description": "Role IDs that may be pinged"
+							},
+							"replied_user": {
+								"type": "boolean",
+								"description": "Whether a reply pings the author of the message replied to"
+							}
+						}
+					},
+					"attachments": {
+						"type": "array",
+						"description": "Up to 10 files, each given as base64 content or a path inside discord.attachment_dir",
+						"maxItems": 10,
+						"items": {
+							"type": "object",
+							"properties": {
+								"filename": {
+									"type": "string",
+									"description": "File name shown in Discord. Required with content_base64"
+								},
+								"content_base64": {
+									"type": "string",
+									"description": "The file's content, base64 encoded. Over HTTP the whole request must fit in 4 MiB, so send larger files by path"
+								},
+								"path": {
+									"type": "string",
+									"description": "Path of a file in the attachment directory"
+								},
+								"content_type": {
+									"type": "string",
+									"description": "MIME type, guessed from the file name if not given"
+								}
+							}
+						}
 					}
 				},
-				"required": ["channel_id", "content"]
+				"required": ["channel_id"]
 			}`),
+			OutputSchema: sendMessageOutputSchema,
 		},
 		{
 			Name:        "get_messages",
-			Description: "Retrieve message history from a Discord channel",
+			Description: "Retrieve message history from a Discord channel or thread",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"channel_id": {
 						"type": "string",
-						"description": "The ID of the channel to get messages from"
+						"description": "The ID of the channel or thread to get messages from"
 					},
 					"limit": {
 						"type": "number",
-						"description": "Number of messages to retrieve (default: 50, max: 100)",
+						"description": "Number of messages to retrieve (default: 50, max: discord.max_history)",
 						"default": 50
+					},
+					"before": {
+						"type": "string",
+						"description": "Only return messages before this message ID, e.g. the cursor from a previous call"
+					},
+					"after": {
+						"type": "string",
+						"description": "Only return messages after this message ID"
+					},
+					"around": {
+						"type": "string",
+						"description": "Return messages around this message ID (max 100)"
 					}
 				},
 				"required": ["channel_id"]
 			}`),
+			OutputSchema: messageListOutputSchema,
 		},
 		{
 			Name:        "get_channel_info",
@@ -60,10 +202,11 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 				},
 				"required": ["channel_id"]
 			}`),
+			OutputSchema: channelOutputSchema,
 		},
 		{
 			Name:        "search_messages",
-			Description: "Search for messages in a Discord channel with filters",
+			Description: "Search message history in one or more Discord channels, or a whole guild, with filters",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -71,6 +214,15 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 						"type": "string",
 						"description": "The ID of the channel to search in"
 					},
+					"channel_ids": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "IDs of further channels to search in"
+					},
+					"guild_id": {
+						"type": "string",
+						"description": "Search every readable channel and active thread, including forum posts, in this guild when no channels are given (defaults to the configured guild). Archived threads are only searched when named as channels"
+					},
 					"content": {
 						"type": "string",
 						"description": "Content to search for (case-insensitive)"
@@ -79,6 +231,39 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 						"type": "string",
 						"description": "Filter by user ID"
 					},
+					"pattern": {
+						"type": "string",
+						"description": "Regular expression (RE2 syntax) the content must match"
+					},
+					"has_attachment": {
+						"type": "boolean",
+						"description": "Only messages with (true) or without (false) attachments"
+					},
+					"has_link": {
+						"type": "boolean",
+						"description": "Only messages with (true) or without (false) links"
+					},
+					"has_embed": {
+						"type": "boolean",
+						"description": "Only messages with (true) or without (false) embeds"
+					},
+					"pinned": {
+						"type": "boolean",
+						"description": "Only pinned (true) or unpinned (false) messages"
+					},
+					"mentions_user_id": {
+						"type": "string",
+						"description": "Only messages mentioning this user"
+					},
+					"mentions_role_id": {
+						"type": "string",
+						"description": "Only messages mentioning this role"
+					},
+					"author_type": {
+						"type": "string",
+						"enum": ["any", "bot", "human"],
+						"description": "Only messages from bots or from humans (default: any)"
+					},
 					"before": {
 						"type": "string",
 						"description": "Search messages before this timestamp (ISO 8601)"
@@ -92,24 +277,29 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 						"description": "Maximum number of messages to return (default: 50)",
 						"default": 50
 					}
-				},
-				"required": ["channel_id"]
+				}
 			}`),
+			OutputSchema: messageListOutputSchema,
 		},
 		{
 			Name:        "moderate_content",
-			Description: "Perform moderation actions (delete messages, kick/ban users)",
+			Description: "Perform moderation actions: delete messages one at a time or in bulk by filter, time out, kick, ban and unban users, list bans and set nicknames. Set dry_run to preview an action without changing anything",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"action": {
 						"type": "string",
-						"enum": ["delete_message", "kick_user", "ban_user"],
+						"enum": ["delete_message", "bulk_delete", "kick_user", "ban_user", "timeout_user", "remove_timeout", "unban_user", "list_bans", "set_nickname"],
 						"description": "The moderation action to perform"
 					},
 					"channel_id": {
 						"type": "string",
-						"description": "Channel ID (required for delete_message)"
+						"description": "Channel ID (required for delete_message; with bulk_delete, the channel to delete from)"
+					},
+					"channel_ids": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "With bulk_delete, further channels to delete from. Without any channel, bulk_delete covers the whole guild"
 					},
 					"message_id": {
 						"type": "string",
@@ -117,11 +307,45 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 					},
 					"guild_id": {
 						"type": "string",
-						"description": "Guild ID (required for kick_user and ban_user)"
+						"description": "Guild ID (required for kick_user and ban_user; other actions default to the configured guild)"
 					},
 					"user_id": {
 						"type": "string",
-						"description": "User ID (required for kick_user and ban_user)"
+						"description": "User ID (required for the user actions; with bulk_delete, only delete this user's messages)"
+					},
+					"content": {
+						"type": "string",
+						"description": "With bulk_delete, only delete messages containing this text (case-insensitive)"
+					},
+					"pattern": {
+						"type": "string",
+						"description": "With bulk_delete, only delete messages matching this regular expression"
+					},
+					"author_type": {
+						"type": "string",
+						"enum": ["any", "bot", "human"],
+						"description": "With bulk_delete, only delete messages from bots or from humans"
+					},
+					"before": {
+						"type": "string",
+						"description": "With bulk_delete, only delete messages sent before this timestamp (ISO 8601)"
+					},
+					"duration": {
+						"type": "string",
+						"description": "How long timeout_user lasts, e.g. \"10m\", \"2h\" or \"7d\" (at most 28 days)"
+					},
+					"nickname": {
+						"type": "string",
+						"description": "The new nickname for set_nickname, or an empty string to reset it"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of bans list_bans returns, or messages bulk_delete deletes (default: 100, max: 1000)",
+						"default": 100
+					},
+					"after": {
+						"type": "string",
+						"description": "With list_bans, only bans of users with IDs after this one, e.g. the cursor from a previous call. With bulk_delete, only delete messages sent after this timestamp (ISO 8601)"
 					},
 					"reason": {
 						"type": "string",
@@ -131,175 +355,337 @@ func (s *Server) handleToolsList(request JSONRPCRequest) error {
 						"type": "number",
 						"description": "Days of messages to delete when banning (0-7, default: 0)",
 						"default": 0
+					},
+					"dry_run": {
+						"type": "boolean",
+						"description": "Resolve the target and check the bot's permissions, then report what the action would do without doing it",
+						"default": false
 					}
 				},
 				"required": ["action"]
 			}`),
+			OutputSchema: moderationOutputSchema,
 		},
 	}
-
-	response := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result: ListToolsResult{
-			Tools: tools,
-		},
+	tools = append(tools, messageTools...)
+	tools = append(tools, threadTools...)
+	tools = append(tools, forumTools...)
+	tools = append(tools, channelTools...)
+	tools = append(tools, memberTools...)
+	tools = append(tools, roleTools...)
+	if s.messageIndex != nil {
+		tools = append(tools, searchIndexTool)
 	}
-
-	return s.sendResponse(response)
+	return tools
 }
 
-func (s *Server) handleToolsCall(request JSONRPCRequest) error {
+func (s *Server) handleToolsCall(ctx context.Context, sess *session, w messageWriter, request JSONRPCRequest) error {
+	claims := sess.caller()
+	if claims == nil {
+		s.sendError(w, request.ID, Unauthorized, "Authentication required")
+		return nil
+	}
+
 	params, ok := request.Params.(map[string]interface{})
 	if !ok {
-		s.sendError(request.ID, InvalidParams, "Invalid parameters")
+		s.sendError(w, request.ID, InvalidParams, "Invalid parameters")
 		return nil
 	}
 
 	toolName, ok := params["name"].(string)
 	if !ok {
-		s.sendError(request.ID, InvalidParams, "Invalid tool name")
+		s.sendError(w, request.ID, InvalidParams, "Invalid tool name")
 		return nil
 	}
 
 	args, ok := params["arguments"].(map[string]interface{})
 	if !ok {
-		s.sendError(request.ID, InvalidParams, "Invalid arguments")
+		s.sendError(w, request.ID, InvalidParams, "Invalid arguments")
 		return nil
 	}
 
+	start := time.Now()
+	result, code, err := s.callTool(ctx, claims, toolName, args)
+
+	var audited interface{}
+	if err == nil {
+		audited = result
+	}
+	s.authManager.Auditor().LogToolCall(toolName, claims.UserID, auditArgs(args), audited, err, time.Since(start))
+
+	if err != nil {
+		s.sendError(w, request.ID, code, err.Error())
+		return nil
+	}
+	if s.sessionProtocolVersion(sess) != structuredOutputVersion {
+		result.StructuredContent = nil
+	}
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  result,
+	}
+
+	return s.sendResponse(w, response)
+}
+
+// callTool checks that the caller may run the tool and runs it, returning
+// the JSON-RPC error code to report if it fails.
+func (s *Server) callTool(ctx context.Context, claims *auth.Claims, toolName string, args map[string]interface{}) (CallToolResult, int, error) {
+	permission, ok := requiredPermission(toolName, args)
+	if !ok {
+		// Tools without a permission mapping are refused rather than
+		// open to every caller
+		return CallToolResult{}, MethodNotFound, fmt.Errorf("unknown tool: %s", toolName)
+	}
+	if !claims.HasPermission(permission) {
+		s.logger.WithFields(logrus.Fields{
+			"tool":       toolName,
+			"user_id":    claims.UserID,
+			"permission": permission,
+		}).Warn("Tool call denied")
+		return CallToolResult{}, Forbidden, fmt.Errorf("permission %s required", permission)
+	}
+
+	if err := s.checkAllowedRoles(ctx, claims, toolName, args); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"tool":    toolName,
+			"user_id": claims.UserID,
+		}).Warn("Tool call denied")
+		return CallToolResult{}, Forbidden, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tool":    toolName,
+		"user_id": claims.UserID,
+	}).Info("Calling tool")
+
 	var result CallToolResult
 	var err error
 
 	switch toolName {
 	case "send_message":
-		result, err = s.handleSendMessage(args)
+		result, err = s.handleSendMessage(ctx, args)
 	case "get_messages":
-		result, err = s.handleGetMessages(args)
+		result, err = s.handleGetMessages(ctx, args)
 	case "get_channel_info":
-		result, err = s.handleGetChannelInfo(args)
+		result, err = s.handleGetChannelInfo(ctx, args)
 	case "search_messages":
-		result, err = s.handleSearchMessages(args)
+		result, err = s.handleSearchMessages(ctx, args)
 	case "moderate_content":
-		result, err = s.handleModerateContent(args)
+		result, err = s.handleModerateContent(ctx, args)
+	case "edit_message":
+		result, err = s.handleEditMessage(ctx, args)
+	case "pin_message":
+		result, err = s.handlePinMessage(ctx, args, true)
+	case "unpin_message":
+		result, err = s.handlePinMessage(ctx, args, false)
+	case "add_reaction":
+		result, err = s.handleAddReaction(ctx, args)
+	case "remove_reaction":
+		result, err = s.handleRemoveReaction(ctx, args)
+	case "list_reactions":
+		result, err = s.handleListReactions(ctx, args)
+	case "create_thread":
+		result, err = s.handleCreateThread(ctx, args)
+	case "list_threads":
+		result, err = s.handleListThreads(ctx, args)
+	case "join_thread":
+		result, err = s.handleJoinThread(ctx, args, true)
+	case "leave_thread":
+		result, err = s.handleJoinThread(ctx, args, false)
+	case "archive_thread":
+		result, err = s.handleArchiveThread(ctx, args)
+	case "list_forum_posts":
+		result, err = s.handleListForumPosts(ctx, args)
+	case "create_forum_post":
+		result, err = s.handleCreateForumPost(ctx, args)
+	case "manage_forum_tags":
+		result, err = s.handleManageForumTags(ctx, args)
+	case "list_channels":
+		result, err = s.handleListChannels(ctx, args)
+	case "create_channel":
+		result, err = s.handleCreateChannel(ctx, args)
+	case "edit_channel":
+		result, err = s.handleEditChannel(ctx, args)
+	case "delete_channel":
+		result, err = s.handleDeleteChannel(ctx, args)
+	case "reorder_channels":
+		result, err = s.handleReorderChannels(ctx, args)
+	case "list_members":
+		result, err = s.handleListMembers(ctx, args)
+	case "search_members":
+		result, err = s.handleSearchMembers(ctx, args)
+	case "get_member":
+		result, err = s.handleGetMember(ctx, args)
+	case "list_roles":
+		result, err = s.handleListRoles(ctx, args)
+	case "create_role":
+		result, err = s.handleCreateRole(ctx, claims, args)
+	case "edit_role":
+		result, err = s.handleEditRole(ctx, claims, args)
+	case "delete_role":
+		result, err = s.handleDeleteRole(ctx, claims, args)
+	case "add_member_role":
+		result, err = s.handleMemberRole(ctx, claims, args, true)
+	case "remove_member_role":
+		result, err = s.handleMemberRole(ctx, claims, args, false)
+	case "search_index":
+		result, err = s.handleSearchIndex(ctx, args)
 	default:
-		s.sendError(request.ID, MethodNotFound, "Unknown tool")
-		return nil
+		return CallToolResult{}, MethodNotFound, fmt.Errorf("unknown tool: %s", toolName)
 	}
 
 	if err != nil {
-		s.sendError(request.ID, InternalError, err.Error())
-		return nil
+		return CallToolResult{}, InternalError, err
 	}
 
-	response := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result:  result,
-	}
-
-	return s.sendResponse(response)
+	return result, 0, nil
 }
 
-func (s *Server) handleSendMessage(args map[string]interface{}) (CallToolResult, error) {
+func (s *Server) handleSendMessage(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
 	channelID, ok := args["channel_id"].(string)
 	if !ok {
 		return CallToolResult{}, fmt.Errorf("channel_id is required")
 	}
 
-	content, ok := args["content"].(string)
-	if !ok {
-		return CallToolResult{}, fmt.Errorf("content is required")
+	send, err := s.buildMessageSend(channelID, args)
+	if err != nil {
+		return CallToolResult{}, err
 	}
 
-	message, err := s.discordClient.SendMessage(channelID, content)
+	message, err := s.discordClient.SendMessage(ctx, channelID, send)
 	if err != nil {
 		return CallToolResult{}, fmt.Errorf("failed to send message: %w", err)
 	}
 
-	return CallToolResult{
-		Content: []ToolContent{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("Message sent successfully. Message ID: %s", message.ID),
-			},
-		},
-	}, nil
+	return structuredResult(
+		fmt.Sprintf("Message sent successfully. Message ID: %s", message.ID),
+		SendMessageResult{Message: toDiscordMessage(message)},
+	), nil
 }
 
-func (s *Server) handleGetMessages(args map[string]interface{}) (CallToolResult, error) {
+func (s *Server) handleGetMessages(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
 	channelID, ok := args["channel_id"].(string)
 	if !ok {
 		return CallToolResult{}, fmt.Errorf("channel_id is required")
 	}
 
-	limit := 50
+	opts := discord.HistoryOptions{Limit: 50}
 	if l, ok := args["limit"].(float64); ok {
-		limit = int(l)
-		if limit > 100 {
-			limit = 100
-		}
+		opts.Limit = int(l)
 	}
+	opts.Before, _ = args["before"].(string)
+	opts.After, _ = args["after"].(string)
+	opts.Around, _ = args["around"].(string)
 
-	messages, err := s.discordClient.GetMessages(channelID, limit)
+	messages, cursor, err := s.discordClient.GetMessages(ctx, channelID, opts)
 	if err != nil {
 		return CallToolResult{}, fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	var messageTexts []string
-	for _, msg := range messages {
-		messageTexts = append(messageTexts, fmt.Sprintf("[%s] %s: %s",
-			msg.Timestamp.Format(time.RFC3339),
-			msg.Author.Username,
-			msg.Content))
+	result := MessageListResult{
+		ChannelID: channelID,
+		Count:     len(messages),
+		Messages:  toDiscordMessages(messages),
 	}
 
 	resultText := fmt.Sprintf("Retrieved %d messages from channel %s:\n%s",
-		len(messages), channelID, fmt.Sprintf("%v", messageTexts))
-
-	return CallToolResult{
-		Content: []ToolContent{
-			{
-				Type: "text",
-				Text: resultText,
-			},
-		},
-	}, nil
+		result.Count, channelID, formatMessageLines(result.Messages, false))
+
+	// Tell the caller how to fetch the next page
+	if cursor != "" {
+		direction := "before"
+		if opts.After != "" {
+			direction = "after"
+			result.NextAfter = cursor
+		} else {
+			result.NextBefore = cursor
+		}
+		resultText += fmt.Sprintf("More messages may be available; continue with %s=%q.", direction, cursor)
+	}
+
+	return structuredResult(resultText, result), nil
 }
 
-func (s *Server) handleGetChannelInfo(args map[string]interface{}) (CallToolResult, error) {
+func (s *Server) handleGetChannelInfo(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
 	channelID, ok := args["channel_id"].(string)
 	if !ok {
 		return CallToolResult{}, fmt.Errorf("channel_id is required")
 	}
 
-	channel, err := s.discordClient.GetChannelInfo(channelID)
+	channel, err := s.discordClient.GetChannelInfo(ctx, channelID)
 	if err != nil {
 		return CallToolResult{}, fmt.Errorf("failed to get channel info: %w", err)
 	}
 
-	resultText := fmt.Sprintf("Channel Info:\nID: %s\nName: %s\nType: %d\nGuild ID: %s\nPosition: %d",
-		channel.ID, channel.Name, channel.Type, channel.GuildID, channel.Position)
+	info := toDiscordChannel(channel)
+	resultText := fmt.Sprintf("Channel Info:\nID: %s\nName: %s\nType: %s\nGuild ID: %s\nPosition: %d",
+		info.ID, info.Name, info.TypeName, info.GuildID, info.Position)
+	if len(info.AvailableTags) > 0 {
+		names := make([]string, 0, len(info.AvailableTags))
+		for _, tag := range info.AvailableTags {
+			names = append(names, tag.Name)
+		}
+		resultText += fmt.Sprintf("\nTags: %s", strings.Join(names, ", "))
+	}
 
-	return CallToolResult{
-		Content: []ToolContent{
-			{
-				Type: "text",
-				Text: resultText,
-			},
-		},
-	}, nil
+	return structuredResult(resultText, info), nil
 }
 
-func (s *Server) handleSearchMessages(args map[string]interface{}) (CallToolResult, error) {
-	channelID, ok := args["channel_id"].(string)
-	if !ok {
-		return CallToolResult{}, fmt.Errorf("channel_id is required")
+func (s *Server) handleSearchMessages(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	filter, err := s.messageFilterArgs(args, 50)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	messages, err := s.discordClient.SearchMessages(ctx, filter)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to search messages: %w", err)
 	}
 
+	// Name the channel when results can come from more than one
+	multiChannel := filter.ChannelID == "" || len(filter.ChannelIDs) > 0
+
+	result := MessageListResult{
+		Count:    len(messages),
+		Messages: toDiscordMessages(messages),
+	}
+	if !multiChannel {
+		result.ChannelID = filter.ChannelID
+	}
+
+	resultText := fmt.Sprintf("Found %d messages matching criteria:\n%s",
+		result.Count, formatMessageLines(result.Messages, multiChannel))
+
+	return structuredResult(resultText, result), nil
+}
+
+// messageFilterArgs builds a message filter from the search_messages style
+// arguments in args: the channels or guild to look in and the criteria
+// messages must meet.
+func (s *Server) messageFilterArgs(args map[string]interface{}, defaultLimit int) (discord.MessageFilter, error) {
 	filter := discord.MessageFilter{
-		ChannelID: channelID,
-		Limit:     50,
+		Limit: defaultLimit,
+	}
+
+	filter.ChannelID, _ = args["channel_id"].(string)
+	if ids, ok := args["channel_ids"].([]interface{}); ok {
+		for _, id := range ids {
+			if id, ok := id.(string); ok && id != "" {
+				filter.ChannelIDs = append(filter.ChannelIDs, id)
+			}
+		}
+	}
+	if filter.ChannelID == "" && len(filter.ChannelIDs) == 0 {
+		filter.GuildID, _ = args["guild_id"].(string)
+		if filter.GuildID == "" {
+			filter.GuildID = s.config.Discord.GuildID
+		}
+		if filter.GuildID == "" {
+			return filter, fmt.Errorf("channel_id, channel_ids or guild_id is required")
+		}
 	}
 
 	if content, ok := args["content"].(string); ok {
@@ -310,6 +696,29 @@ func (s *Server) handleSearchMessages(args map[string]interface{}) (CallToolResu
 		filter.UserID = userID
 	}
 
+	if pattern, ok := args["pattern"].(string); ok && pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return filter, fmt.Errorf("invalid pattern: %w", err)
+		}
+		filter.Pattern = re
+	}
+
+	filter.HasAttachment = boolArg(args, "has_attachment")
+	filter.HasLink = boolArg(args, "has_link")
+	filter.HasEmbed = boolArg(args, "has_embed")
+	filter.Pinned = boolArg(args, "pinned")
+	filter.MentionsUser, _ = args["mentions_user_id"].(string)
+	filter.MentionsRole, _ = args["mentions_role_id"].(string)
+
+	switch authorType, _ := args["author_type"].(string); authorType {
+	case "", "any":
+	case discord.AuthorBot, discord.AuthorHuman:
+		filter.AuthorType = authorType
+	default:
+		return filter, fmt.Errorf("unknown author_type: %s", authorType)
+	}
+
 	if limit, ok := args["limit"].(float64); ok {
 		filter.Limit = int(limit)
 	}
@@ -326,33 +735,19 @@ func (s *Server) handleSearchMessages(args map[string]interface{}) (CallToolResu
 		}
 	}
 
-	messages, err := s.discordClient.SearchMessages(filter)
-	if err != nil {
-		return CallToolResult{}, fmt.Errorf("failed to search messages: %w", err)
-	}
+	return filter, nil
+}
 
-	var messageTexts []string
-	for _, msg := range messages {
-		messageTexts = append(messageTexts, fmt.Sprintf("[%s] %s: %s",
-			msg.Timestamp.Format(time.RFC3339),
-			msg.Author.Username,
-			msg.Content))
+// boolArg returns a pointer to the boolean argument name, or nil if it was
+// not given.
+func boolArg(args map[string]interface{}, name string) *bool {
+	if v, ok := args[name].(bool); ok {
+		return &v
 	}
-
-	resultText := fmt.Sprintf("Found %d messages matching criteria:\n%s",
-		len(messages), fmt.Sprintf("%v", messageTexts))
-
-	return CallToolResult{
-		Content: []ToolContent{
-			{
-				Type: "text",
-				Text: resultText,
-			},
-		},
-	}, nil
+	return nil
 }
 
-func (s *Server) handleModerateContent(args map[string]interface{}) (CallToolResult, error) {
+func (s *Server) handleModerateContent(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
 	action, ok := args["action"].(string)
 	if !ok {
 		return CallToolResult{}, fmt.Errorf("action is required")
@@ -363,6 +758,12 @@ func (s *Server) handleModerateContent(args map[string]interface{}) (CallToolRes
 		reason = r
 	}
 
+	// dry_run cannot turn off a dry run mode set in the config
+	dryRun := s.config.Discord.ModerationDryRun
+	if d, ok := args["dry_run"].(bool); ok && d {
+		dryRun = true
+	}
+
 	switch action {
 	case "delete_message":
 		channelID, ok := args["channel_id"].(string)
@@ -375,19 +776,27 @@ func (s *Server) handleModerateContent(args map[string]interface{}) (CallToolRes
 			return CallToolResult{}, fmt.Errorf("message_id is required for delete_message")
 		}
 
-		err := s.discordClient.DeleteMessage(channelID, messageID)
+		if dryRun {
+			msg, err := s.discordClient.CheckMessageDelete(ctx, channelID, messageID)
+			if err != nil {
+				return CallToolResult{}, err
+			}
+			m := toDiscordMessage(msg)
+			return dryRunResult(
+				fmt.Sprintf("delete message %s by %s: %s", messageID, m.Author, m.Content),
+				ModerationResult{Action: action, ChannelID: channelID, MessageID: messageID, Reason: reason, Message: &m},
+			), nil
+		}
+
+		err := s.discordClient.DeleteMessage(ctx, channelID, messageID)
 		if err != nil {
 			return CallToolResult{}, fmt.Errorf("failed to delete message: %w", err)
 		}
 
-		return CallToolResult{
-			Content: []ToolContent{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("Message %s deleted successfully", messageID),
-				},
-			},
-		}, nil
+		return structuredResult(
+			fmt.Sprintf("Message %s deleted successfully", messageID),
+			ModerationResult{Action: action, ChannelID: channelID, MessageID: messageID, Reason: reason},
+		), nil
 
 	case "kick_user":
 		guildID, ok := args["guild_id"].(string)
@@ -395,24 +804,27 @@ func (s *Server) handleModerateContent(args map[string]interface{}) (CallToolRes
 			return CallToolResult{}, fmt.Errorf("guild_id is required for kick_user")
 		}
 
-		userID, ok := args["user_id"].(string)
-		if !ok {
-			return CallToolResult{}, fmt.Errorf("user_id is required for kick_user")
+		userID, err := userIDArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+
+		if dryRun {
+			return s.previewModeration(ctx,
+				fmt.Sprintf("kick user %s. Reason: %s", userID, reason),
+				ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason},
+			)
 		}
 
-		err := s.discordClient.KickUser(guildID, userID, reason)
+		err = s.discordClient.KickUser(ctx, guildID, userID, reason)
 		if err != nil {
 			return CallToolResult{}, fmt.Errorf("failed to kick user: %w", err)
 		}
 
-		return CallToolResult{
-			Content: []ToolContent{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("User %s kicked successfully. Reason: %s", userID, reason),
-				},
-			},
-		}, nil
+		return structuredResult(
+			fmt.Sprintf("User %s kicked successfully. Reason: %s", userID, reason),
+			ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason},
+		), nil
 
 	case "ban_user":
 		guildID, ok := args["guild_id"].(string)
@@ -420,9 +832,9 @@ func (s *Server) handleModerateContent(args map[string]interface{}) (CallToolRes
 			return CallToolResult{}, fmt.Errorf("guild_id is required for ban_user")
 		}
 
-		userID, ok := args["user_id"].(string)
-		if !ok {
-			return CallToolResult{}, fmt.Errorf("user_id is required for ban_user")
+		userID, err := userIDArg(args)
+		if err != nil {
+			return CallToolResult{}, err
 		}
 
 		deleteMessageDays := 0
@@ -433,54 +845,394 @@ func (s *Server) handleModerateContent(args map[string]interface{}) (CallToolRes
 			}
 		}
 
-		err := s.discordClient.BanUser(guildID, userID, reason, deleteMessageDays)
+		if dryRun {
+			return s.previewModeration(ctx,
+				fmt.Sprintf("ban user %s, deleting %d days of their messages. Reason: %s", userID, deleteMessageDays, reason),
+				ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason},
+			)
+		}
+
+		err = s.discordClient.BanUser(ctx, guildID, userID, reason, deleteMessageDays)
 		if err != nil {
 			return CallToolResult{}, fmt.Errorf("failed to ban user: %w", err)
 		}
 
-		return CallToolResult{
-			Content: []ToolContent{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("User %s banned successfully. Reason: %s", userID, reason),
+		return structuredResult(
+			fmt.Sprintf("User %s banned successfully. Reason: %s", userID, reason),
+			ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason},
+		), nil
+
+	case "timeout_user", "remove_timeout":
+		guildID, err := s.guildArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		userID, err := userIDArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+
+		var until *time.Time
+		if action == "timeout_user" {
+			raw, _ := args["duration"].(string)
+			if raw == "" {
+				return CallToolResult{}, fmt.Errorf("duration is required for timeout_user")
+			}
+			duration, err := parseTimeoutDuration(raw)
+			if err != nil {
+				return CallToolResult{}, err
+			}
+			t := time.Now().Add(duration).UTC().Truncate(time.Second)
+			until = &t
+		}
+
+		if dryRun {
+			result := ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason}
+			if until == nil {
+				return s.previewModeration(ctx, fmt.Sprintf("remove the timeout of user %s", userID), result)
+			}
+			result.Until = until.Format(time.RFC3339)
+			return s.previewModeration(ctx,
+				fmt.Sprintf("time out user %s until %s. Reason: %s", userID, result.Until, reason),
+				result,
+			)
+		}
+
+		if err := s.discordClient.TimeoutUser(ctx, guildID, userID, until, reason); err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to update timeout: %w", err)
+		}
+
+		result := ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason}
+		if until == nil {
+			return structuredResult(fmt.Sprintf("Timeout removed for user %s", userID), result), nil
+		}
+		result.Until = until.Format(time.RFC3339)
+		return structuredResult(
+			fmt.Sprintf("User %s timed out until %s. Reason: %s", userID, result.Until, reason),
+			result,
+		), nil
+
+	case "unban_user":
+		guildID, err := s.guildArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		userID, err := userIDArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+
+		if dryRun {
+			return s.previewModeration(ctx,
+				fmt.Sprintf("unban user %s", userID),
+				ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason},
+			)
+		}
+
+		if err := s.discordClient.UnbanUser(ctx, guildID, userID, reason); err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to unban user: %w", err)
+		}
+
+		return structuredResult(
+			fmt.Sprintf("User %s unbanned successfully", userID),
+			ModerationResult{Action: action, GuildID: guildID, UserID: userID, Reason: reason},
+		), nil
+
+	case "list_bans":
+		guildID, err := s.guildArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		limit := 100
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		if limit < 1 || limit > maxBansPage {
+			return CallToolResult{}, fmt.Errorf("limit must be between 1 and %d", maxBansPage)
+		}
+		after, _ := args["after"].(string)
+
+		bans, err := s.discordClient.GetBans(ctx, guildID, limit, after)
+		if err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to list bans: %w", err)
+		}
+
+		result := ModerationResult{Action: action, GuildID: guildID, Bans: make([]DiscordBan, 0, len(bans))}
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d bans in guild %s:\n", len(bans), guildID)
+		for _, ban := range bans {
+			entry := DiscordBan{Reason: ban.Reason}
+			if ban.User != nil {
+				entry.User = DiscordUser{ID: ban.User.ID, Username: ban.User.Username, Bot: ban.User.Bot}
+			}
+			result.Bans = append(result.Bans, entry)
+			fmt.Fprintf(&b, "%s (%s)", entry.User.Username, entry.User.ID)
+			if entry.Reason != "" {
+				fmt.Fprintf(&b, ": %s", entry.Reason)
+			}
+			b.WriteString("\n")
+		}
+		if len(bans) == limit {
+			result.NextAfter = result.Bans[len(bans)-1].User.ID
+			fmt.Fprintf(&b, "More bans may be available; continue with after=%q.", result.NextAfter)
+		}
+		return structuredResult(b.String(), result), nil
+
+	case "set_nickname":
+		guildID, err := s.guildArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		userID, err := userIDArg(args)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		nickname, ok := args["nickname"].(string)
+		if !ok {
+			return CallToolResult{}, fmt.Errorf("nickname is required for set_nickname; pass an empty string to reset it")
+		}
+		if len([]rune(nickname)) > maxNicknameLength {
+			return CallToolResult{}, fmt.Errorf("nickname must be at most %d characters", maxNicknameLength)
+		}
+
+		result := ModerationResult{Action: action, GuildID: guildID, UserID: userID, Nickname: &nickname, Reason: reason}
+		if dryRun {
+			text := fmt.Sprintf("set the nickname of user %s to %q", userID, nickname)
+			if nickname == "" {
+				text = fmt.Sprintf("reset the nickname of user %s", userID)
+			}
+			return s.previewModeration(ctx, text, result)
+		}
+
+		if err := s.discordClient.SetNickname(ctx, guildID, userID, nickname, reason); err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to set nickname: %w", err)
+		}
+
+		text := fmt.Sprintf("Nickname of user %s set to %q", userID, nickname)
+		if nickname == "" {
+			text = fmt.Sprintf("Nickname of user %s reset", userID)
+		}
+		return structuredResult(text, result), nil
+
+	case "bulk_delete":
+		// A mistyped time bound must not widen what gets deleted
+		for _, name := range []string{"before", "after"} {
+			if raw, ok := args[name].(string); ok {
+				if _, err := time.Parse(time.RFC3339, raw); err != nil {
+					return CallToolResult{}, fmt.Errorf("invalid %s timestamp: %w", name, err)
+				}
+			}
+		}
+
+		filter, err := s.messageFilterArgs(args, 100)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		if filter.Limit < 1 || filter.Limit > maxBulkDelete {
+			return CallToolResult{}, fmt.Errorf("limit must be between 1 and %d", maxBulkDelete)
+		}
+		if filter.GuildID != "" && !hasMessageCriteria(filter) {
+			return CallToolResult{}, fmt.Errorf("bulk_delete across a guild needs criteria such as user_id, content or pattern")
+		}
+
+		messages, err := s.discordClient.SearchMessages(ctx, filter)
+		if err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to find messages: %w", err)
+		}
+
+		if dryRun {
+			if err := s.checkBulkDelete(ctx, filter); err != nil {
+				return CallToolResult{}, err
+			}
+			planned := discord.PlanDeletes(messages)
+			summary := &BulkDeleteSummary{
+				Matched:  len(messages),
+				Bulk:     planned.Bulk,
+				Single:   planned.Single,
+				Messages: toDiscordMessages(messages),
+			}
+			return dryRunResult(
+				fmt.Sprintf("delete %d matching messages (%d in bulk, %d individually):\n%s",
+					summary.Matched, summary.Bulk, summary.Single, formatMessageLines(summary.Messages, true)),
+				ModerationResult{
+					Action:     action,
+					GuildID:    filter.GuildID,
+					ChannelID:  filter.ChannelID,
+					UserID:     filter.UserID,
+					Reason:     reason,
+					BulkDelete: summary,
 				},
-			},
-		}, nil
+			), nil
+		}
+
+		deleted := s.discordClient.DeleteMessages(ctx, messages, reason)
+
+		summary := &BulkDeleteSummary{
+			Matched:  len(messages),
+			Deleted:  deleted.Bulk + deleted.Single,
+			Bulk:     deleted.Bulk,
+			Single:   deleted.Single,
+			Failed:   len(deleted.Failures),
+			Failures: make([]BulkDeleteFailure, 0, len(deleted.Failures)),
+		}
+		for _, f := range deleted.Failures {
+			summary.Failures = append(summary.Failures, BulkDeleteFailure{
+				ChannelID: f.ChannelID,
+				MessageID: f.MessageID,
+				Error:     f.Err.Error(),
+			})
+		}
+
+		text := fmt.Sprintf("Deleted %d of %d matching messages (%d in bulk, %d individually)",
+			summary.Deleted, summary.Matched, summary.Bulk, summary.Single)
+		if summary.Failed > 0 {
+			text += fmt.Sprintf(". %d failed, first error: %s", summary.Failed, summary.Failures[0].Error)
+		}
+		return structuredResult(text, ModerationResult{
+			Action:     action,
+			GuildID:    filter.GuildID,
+			ChannelID:  filter.ChannelID,
+			UserID:     filter.UserID,
+			Reason:     reason,
+			BulkDelete: summary,
+		}), nil
 
 	default:
 		return CallToolResult{}, fmt.Errorf("unknown moderation action: %s", action)
 	}
 }
 
-func (s *Server) handleResourcesList(request JSONRPCRequest) error {
-	response := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result: map[string]interface{}{
-			"resources": []interface{}{},
-		},
+// moderationBotPermissions are the Discord permissions the bot needs for
+// each moderate_content action that changes something.
+var moderationBotPermissions = map[string]int64{
+	"delete_message": discordgo.PermissionManageMessages,
+	"bulk_delete":    discordgo.PermissionManageMessages,
+	"kick_user":      discordgo.PermissionKickMembers,
+	"ban_user":       discordgo.PermissionBanMembers,
+	"unban_user":     discordgo.PermissionBanMembers,
+	"timeout_user":   discordgo.PermissionModerateMembers,
+	"remove_timeout": discordgo.PermissionModerateMembers,
+	"set_nickname":   discordgo.PermissionManageNicknames,
+}
+
+// previewModeration is the dry run of a moderate_content action on a
+// user. It resolves the user and checks that the bot could act on them,
+// then reports what the action would do.
+func (s *Server) previewModeration(ctx context.Context, text string, result ModerationResult) (CallToolResult, error) {
+	member, err := s.discordClient.CheckModeration(ctx, result.GuildID, result.UserID, moderationBotPermissions[result.Action])
+	if err != nil {
+		return CallToolResult{}, err
 	}
 
-	return s.sendResponse(response)
+	switch result.Action {
+	case "ban_user":
+		// Users who are not members can be banned too
+		if member == nil {
+			text += fmt.Sprintf(". User %s is not a member of guild %s", result.UserID, result.GuildID)
+		}
+	case "unban_user":
+		ban, err := s.discordClient.GetBan(ctx, result.GuildID, result.UserID)
+		if err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to get ban: %w", err)
+		}
+		if ban == nil {
+			return CallToolResult{}, fmt.Errorf("user %s is not banned from guild %s", result.UserID, result.GuildID)
+		}
+		if ban.Reason != "" {
+			text += fmt.Sprintf(", banned for: %s", ban.Reason)
+		}
+	default:
+		if member == nil {
+			return CallToolResult{}, fmt.Errorf("user %s is not a member of guild %s", result.UserID, result.GuildID)
+		}
+	}
+
+	if member != nil {
+		roleNames, err := s.roleNames(ctx, result.GuildID)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		target := toDiscordMember(member, roleNames)
+		result.Target = &target
+	}
+	return dryRunResult(text, result), nil
 }
 
-func (s *Server) handlePromptsList(request JSONRPCRequest) error {
-	response := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result: map[string]interface{}{
-			"prompts": []interface{}{},
-		},
+// checkBulkDelete checks that the bot could delete messages in every guild
+// filter covers.
+func (s *Server) checkBulkDelete(ctx context.Context, filter discord.MessageFilter) error {
+	guildIDs := []string{filter.GuildID}
+	if filter.GuildID == "" {
+		guildIDs = nil
+		for _, channelID := range append([]string{filter.ChannelID}, filter.ChannelIDs...) {
+			if channelID == "" {
+				continue
+			}
+			channel, err := s.discordClient.GetChannelInfo(ctx, channelID)
+			if err != nil {
+				return fmt.Errorf("failed to get channel %s: %w", channelID, err)
+			}
+			if !slices.Contains(guildIDs, channel.GuildID) {
+				guildIDs = append(guildIDs, channel.GuildID)
+			}
+		}
+	}
+	for _, guildID := range guildIDs {
+		if _, err := s.discordClient.CheckModeration(ctx, guildID, "", moderationBotPermissions["bulk_delete"]); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// dryRunResult reports what a moderate_content action would have done.
+func dryRunResult(text string, result ModerationResult) CallToolResult {
+	result.DryRun = true
+	return structuredResult("Dry run, nothing was changed. Would "+text, result)
+}
 
-	return s.sendResponse(response)
+// hasMessageCriteria reports whether filter narrows messages down by
+// anything other than where and when they were sent.
+func hasMessageCriteria(filter discord.MessageFilter) bool {
+	return filter.UserID != "" || filter.Content != "" || filter.Pattern != nil ||
+		filter.HasAttachment != nil || filter.HasLink != nil || filter.HasEmbed != nil || filter.Pinned != nil ||
+		filter.MentionsUser != "" || filter.MentionsRole != "" || filter.AuthorType != ""
+}
+
+// Limits on moderate_content arguments.
+const (
+	maxBansPage       = 1000
+	maxNicknameLength = 32
+	maxBulkDelete     = 1000
+)
+
+// parseTimeoutDuration parses a timeout such as "10m", "2h30m" or "7d".
+func parseTimeoutDuration(raw string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(raw)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use e.g. \"10m\", \"2h\" or \"7d\"", raw)
+	}
+	if d <= 0 || d > discord.MaxTimeout {
+		return 0, fmt.Errorf("duration must be positive and at most 28 days")
+	}
+	return d, nil
 }
 
-func (s *Server) handleCancelled(request JSONRPCRequest) error {
+func (s *Server) handleCancelled(sess *session, request JSONRPCRequest) error {
 	if params, ok := request.Params.(map[string]interface{}); ok {
+		cancelled := sess.cancelRequest(params["requestId"])
 		s.logger.WithFields(map[string]interface{}{
 			"request_id": params["requestId"],
 			"reason":     params["reason"],
+			"in_flight":  cancelled,
 		}).Info("Received cancellation notification")
 	}
 	return nil
@@ -0,0 +1,409 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sessionHeader     = "Mcp-Session-Id"
+	apiKeyHeader      = "X-API-Key"
+	maxRequestBody    = 4 << 20
+	keepaliveInterval = 30 * time.Second
+)
+
+// HTTPHandler returns the handler for the MCP Streamable HTTP transport.
+// POST carries client requests, GET opens an SSE stream for server-initiated
+// messages and DELETE ends the session.
+func (s *Server) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.config.MCP.HTTP.Path, s.handleHTTP)
+	return mux
+}
+
+func (s *Server) serveHTTP() error {
+	httpServer := &http.Server{
+		Addr:              s.config.MCP.HTTP.Addr,
+		Handler:           s.HTTPHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.expireSessions(stop)
+
+	s.logger.WithField("addr", httpServer.Addr).Info("Serving MCP over HTTP")
+	return httpServer.ListenAndServe()
+}
+
+func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handleHTTPPost(w, r)
+	case http.MethodGet:
+		s.handleHTTPStream(w, r)
+	case http.MethodDelete:
+		s.handleHTTPDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleHTTPPost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Request body is larger than %d bytes", maxRequestBody), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	requests, batch, err := decodeRequests(body)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to decode request")
+		writeJSON(w, http.StatusBadRequest, JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error:   &JSONRPCError{Code: ParseError, Message: "Failed to parse JSON"},
+		})
+		return
+	}
+
+	claims, err := s.authenticateHTTP(r)
+	if err != nil {
+		var id interface{}
+		if !batch {
+			id = requests[0].ID
+		}
+		writeUnauthorized(w, id)
+		return
+	}
+
+	var sess *session
+	if !batch && requests[0].Method == "initialize" {
+		sess = newSession(claims, nil)
+		s.addSession(sess)
+		w.Header().Set(sessionHeader, sess.id)
+	} else {
+		var status int
+		if sess, status = s.requestSession(r, claims); sess == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+	sess.touch()
+
+	if batch && s.sessionProtocolVersion(sess) == structuredOutputVersion {
+		writeJSON(w, http.StatusBadRequest, JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error:   &JSONRPCError{Code: InvalidRequest, Message: "Batches are not supported in protocol version " + structuredOutputVersion},
+		})
+		return
+	}
+
+	// Requests in a batch run concurrently and are cancelled if the client
+	// disconnects before they finish.
+	replies := &bufferWriter{}
+	var wg sync.WaitGroup
+	for _, request := range requests {
+		ctx, done := sess.startRequest(r.Context(), request)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer done()
+			s.dispatch(ctx, sess, replies, request)
+		}()
+	}
+	wg.Wait()
+
+	messages := replies.collected()
+	switch {
+	case len(messages) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case batch:
+		writeJSON(w, http.StatusOK, messages)
+	default:
+		writeJSON(w, http.StatusOK, messages[0])
+	}
+}
+
+func (s *Server) handleHTTPStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	claims, err := s.authenticateHTTP(r)
+	if err != nil {
+		writeUnauthorized(w, nil)
+		return
+	}
+
+	sess, status := s.requestSession(r, claims)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Attach the stream before the headers go out, holding its lock so
+	// nothing is written ahead of them. Once a client sees the response it
+	// receives every message sent to the session from then on.
+	stream := &sseWriter{w: w, flusher: flusher}
+	stream.mu.Lock()
+	sess.setStream(stream)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	stream.mu.Unlock()
+	defer stream.close()
+	defer sess.clearStream(stream)
+
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sess.touch()
+			if err := stream.comment("keepalive"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-sess.done:
+			return
+		}
+	}
+}
+
+func (s *Server) handleHTTPDelete(w http.ResponseWriter, r *http.Request) {
+	claims, err := s.authenticateHTTP(r)
+	if err != nil {
+		writeUnauthorized(w, nil)
+		return
+	}
+
+	sess, status := s.requestSession(r, claims)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	s.removeSession(sess.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestSession looks up the session named by the request's
+// Mcp-Session-Id header, returning the HTTP status to fail with if there
+// is none or it belongs to a different caller.
+func (s *Server) requestSession(r *http.Request, claims *auth.Claims) (*session, int) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	sess := s.lookupSession(id)
+	if sess == nil {
+		return nil, http.StatusNotFound
+	}
+
+	if !sess.reauthenticate(claims) {
+		s.logger.WithFields(logrus.Fields{
+			"session_id": id,
+			"user_id":    claims.UserID,
+		}).Warn("Rejected request for another caller's session")
+		return nil, http.StatusForbidden
+	}
+	return sess, 0
+}
+
+// authenticateHTTP resolves the caller from the Authorization bearer token
+// or X-API-Key header. When auth.required is off, requests without
+// credentials are let through anonymously with auth.anonymous_permissions.
+func (s *Server) authenticateHTTP(r *http.Request) (*auth.Claims, error) {
+	var bearer string
+	if header := r.Header.Get("Authorization"); len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		bearer = strings.TrimSpace(header[7:])
+	}
+	apiKey := r.Header.Get(apiKeyHeader)
+
+	if bearer == "" && apiKey == "" && !s.config.Auth.Required {
+		return auth.AnonymousClaims(s.config.Auth.AnonymousPermissions), nil
+	}
+
+	claims, err := s.authManager.Authenticate(bearer, apiKey)
+	if err != nil {
+		s.logger.WithError(err).WithField("remote_addr", r.RemoteAddr).Warn("Rejected unauthenticated request")
+		return nil, err
+	}
+	return claims, nil
+}
+
+// originAllowed reports whether a request may be served given its
+// Origin header. Without allowed_origins only the server's own host and
+// localhost are accepted; "*" has to be configured to accept any origin.
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	allowed := s.config.MCP.HTTP.AllowedOrigins
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return true
+		}
+		return strings.EqualFold(u.Host, r.Host)
+	}
+
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// expireSessions closes sessions that have been idle for longer than the
+// configured session timeout.
+func (s *Server) expireSessions(stop <-chan struct{}) {
+	timeout := s.config.MCP.HTTP.SessionTimeout
+	if timeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cutoff := time.Now().Add(-timeout)
+			for _, sess := range s.listSessions() {
+				if sess.idleSince().Before(cutoff) {
+					s.logger.WithField("session_id", sess.id).Info("Expiring idle session")
+					s.removeSession(sess.id)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// decodeRequests parses a POST body holding either a single JSON-RPC
+// message or a batch of them.
+func decodeRequests(body []byte) ([]JSONRPCRequest, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var requests []JSONRPCRequest
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return nil, true, err
+		}
+		if len(requests) == 0 {
+			return nil, true, fmt.Errorf("empty batch")
+		}
+		return requests, true, nil
+	}
+
+	var request JSONRPCRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, false, err
+	}
+	return []JSONRPCRequest{request}, false, nil
+}
+
+func writeUnauthorized(w http.ResponseWriter, id interface{}) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+	writeJSON(w, http.StatusUnauthorized, JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &JSONRPCError{Code: Unauthorized, Message: "Authentication required"},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// sseWriter writes JSON-RPC messages as Server-Sent Events. Once closed,
+// when the stream's handler returns, writes fail rather than touch the
+// finished response.
+type sseWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	flusher http.Flusher
+	closed  bool
+}
+
+func (sw *sseWriter) close() {
+	sw.mu.Lock()
+	sw.closed = true
+	sw.mu.Unlock()
+}
+
+func (sw *sseWriter) writeMessage(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.closed {
+		return errNoStream
+	}
+	if _, err := fmt.Fprintf(sw.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
+
+func (sw *sseWriter) comment(text string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.closed {
+		return errNoStream
+	}
+	if _, err := fmt.Fprintf(sw.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
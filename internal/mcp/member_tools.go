@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberTools look up a guild's members, so a name like "@alex" can be
+// resolved to a user ID.
+var memberTools = []Tool{
+	{
+		Name:        "list_members",
+		Description: "List a guild's members in user ID order, with their roles",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"after": {
+					"type": "string",
+					"description": "Only members with user IDs after this one, e.g. the cursor from a previous call"
+				},
+				"limit": {
+					"type": "number",
+					"description": "Maximum number of members to return (default: 100, max: 10000)",
+					"default": 100
+				}
+			}
+		}`),
+		OutputSchema: memberListOutputSchema,
+	},
+	{
+		Name:        "search_members",
+		Description: "Find guild members whose username or nickname starts with a prefix",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"query": {
+					"type": "string",
+					"description": "The name prefix to match, without the @"
+				},
+				"limit": {
+					"type": "number",
+					"description": "Maximum number of members to return (default: 25, max: 1000)",
+					"default": 25
+				}
+			},
+			"required": ["query"]
+		}`),
+		OutputSchema: memberListOutputSchema,
+	},
+	{
+		Name:        "get_member",
+		Description: "Get a guild member's roles, nickname, join date and timeout status",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"user_id": {
+					"type": "string",
+					"description": "The member's user ID, or a mention such as <@123>"
+				}
+			},
+			"required": ["user_id"]
+		}`),
+		OutputSchema: memberOutputSchema,
+	},
+}
+
+// maxListMembers is the most members list_members returns in one call.
+const maxListMembers = 10000
+
+func (s *Server) handleListMembers(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	after, _ := args["after"].(string)
+
+	limit := 100
+	if l, ok := args["limit"].(float64); ok {
+		limit = int(l)
+	}
+	if limit < 1 || limit > maxListMembers {
+		return CallToolResult{}, fmt.Errorf("limit must be between 1 and %d", maxListMembers)
+	}
+
+	members, err := s.discordClient.GetGuildMembers(ctx, guildID, after, limit)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	result, err := s.memberList(ctx, guildID, members)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	text := fmt.Sprintf("Found %d members in guild %s:\n%s", result.Count, guildID, formatMembers(result.Members))
+	if len(members) == limit {
+		result.NextAfter = members[len(members)-1].User.ID
+		text += fmt.Sprintf("More members may be available; continue with after=%q.", result.NextAfter)
+	}
+	return structuredResult(text, result), nil
+}
+
+func (s *Server) handleSearchMembers(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	query, _ := args["query"].(string)
+	query = strings.TrimPrefix(strings.TrimSpace(query), "@")
+	if query == "" {
+		return CallToolResult{}, fmt.Errorf("query is required")
+	}
+
+	limit := 25
+	if l, ok := args["limit"].(float64); ok {
+		limit = int(l)
+	}
+
+	members, err := s.discordClient.SearchMembers(ctx, guildID, query, limit)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to search members: %w", err)
+	}
+
+	result, err := s.memberList(ctx, guildID, members)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	result.Query = query
+	text := fmt.Sprintf("Found %d members matching %q:\n%s", result.Count, query, formatMembers(result.Members))
+	return structuredResult(text, result), nil
+}
+
+func (s *Server) handleGetMember(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	userID, err := userIDArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	member, err := s.discordClient.GetMember(ctx, guildID, userID)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to get member: %w", err)
+	}
+	roleNames, err := s.roleNames(ctx, guildID)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	m := toDiscordMember(member, roleNames)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Member: %s (@%s, %s)\n", m.DisplayName, m.Username, m.UserID)
+	if m.Nickname != "" {
+		fmt.Fprintf(&b, "Nickname: %s\n", m.Nickname)
+	}
+	if m.JoinedAt != "" {
+		fmt.Fprintf(&b, "Joined: %s\n", m.JoinedAt)
+	}
+	names := make([]string, 0, len(m.Roles))
+	for _, role := range m.Roles {
+		names = append(names, role.Name)
+	}
+	fmt.Fprintf(&b, "Roles: %s\n", strings.Join(names, ", "))
+	if m.TimedOutUntil != "" {
+		fmt.Fprintf(&b, "Timed out until: %s\n", m.TimedOutUntil)
+	}
+	return structuredResult(b.String(), m), nil
+}
+
+// memberList converts members, naming their roles.
+func (s *Server) memberList(ctx context.Context, guildID string, members []*discordgo.Member) (MemberListResult, error) {
+	roleNames, err := s.roleNames(ctx, guildID)
+	if err != nil {
+		return MemberListResult{}, err
+	}
+	result := MemberListResult{
+		GuildID: guildID,
+		Count:   len(members),
+		Members: make([]DiscordMember, 0, len(members)),
+	}
+	for _, member := range members {
+		result.Members = append(result.Members, toDiscordMember(member, roleNames))
+	}
+	return result, nil
+}
+
+// roleNames maps the IDs of guildID's roles to their names.
+func (s *Server) roleNames(ctx context.Context, guildID string) (map[string]string, error) {
+	roles, err := s.discordClient.GetGuildRoles(ctx, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild roles: %w", err)
+	}
+	names := make(map[string]string, len(roles))
+	for _, role := range roles {
+		names[role.ID] = role.Name
+	}
+	return names, nil
+}
+
+// userIDArg returns the user_id argument, accepting a mention such as
+// <@123> or <@!123> in place of the bare ID.
+func userIDArg(args map[string]interface{}) (string, error) {
+	userID, _ := args["user_id"].(string)
+	if userID == "" {
+		return "", fmt.Errorf("user_id is required")
+	}
+	if strings.HasPrefix(userID, "<@") && strings.HasSuffix(userID, ">") {
+		userID = strings.TrimPrefix(strings.TrimSuffix(userID[2:], ">"), "!")
+	}
+	if !utils.ValidateDiscordID(userID) {
+		return "", fmt.Errorf("invalid user_id %q", userID)
+	}
+	return userID, nil
+}
+
+func toDiscordMember(member *discordgo.Member, roleNames map[string]string) DiscordMember {
+	m := DiscordMember{
+		Nickname: member.Nick,
+		Pending:  member.Pending,
+		Roles:    make([]DiscordRef, 0, len(member.Roles)),
+	}
+	if member.User != nil {
+		m.UserID = member.User.ID
+		m.Username = member.User.Username
+		m.GlobalName = member.User.GlobalName
+		m.Bot = member.User.Bot
+		m.DisplayName = member.DisplayName()
+	}
+	if !member.JoinedAt.IsZero() {
+		m.JoinedAt = member.JoinedAt.Format(time.RFC3339)
+	}
+	if until := member.CommunicationDisabledUntil; until != nil && until.After(time.Now()) {
+		m.TimedOutUntil = until.Format(time.RFC3339)
+	}
+	for _, id := range member.Roles {
+		m.Roles = append(m.Roles, DiscordRef{ID: id, Name: roleNames[id]})
+	}
+	return m
+}
+
+// formatMembers renders members one per line.
+func formatMembers(members []DiscordMember) string {
+	var b strings.Builder
+	for _, m := range members {
+		fmt.Fprintf(&b, "%s (@%s, %s)", m.DisplayName, m.Username, m.UserID)
+		if m.Bot {
+			b.WriteString(" [bot]")
+		}
+		if m.TimedOutUntil != "" {
+			fmt.Fprintf(&b, ", timed out until %s", m.TimedOutUntil)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
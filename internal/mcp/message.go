@@ -0,0 +1,385 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Discord accepts at most 10 embeds and 10 files per message.
+	maxEmbeds      = 10
+	maxAttachments = 10
+
+	// maxAttachmentSize stops the server buffering very large files. Discord
+	// still enforces the guild's own, usually smaller, upload limit. Over
+	// HTTP, base64 content is further bounded by maxRequestBody, so larger
+	// files must come from the attachment directory.
+	maxAttachmentSize = 25 << 20
+)
+
+// defaultAllowedMentions lets users and roles be pinged, and replies ping
+// their author, but never @everyone or @here unless a caller asks for it.
+func defaultAllowedMentions() *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{
+			discordgo.AllowedMentionTypeUsers,
+			discordgo.AllowedMentionTypeRoles,
+		},
+		RepliedUser: true,
+	}
+}
+
+// buildMessageSend builds the message described by send_message's
+// arguments.
+func (s *Server) buildMessageSend(channelID string, args map[string]interface{}) (*discordgo.MessageSend, error) {
+	msg := &discordgo.MessageSend{
+		AllowedMentions: defaultAllowedMentions(),
+	}
+	msg.Content, _ = args["content"].(string)
+	msg.TTS, _ = args["tts"].(bool)
+
+	if replyTo, ok := args["reply_to"].(string); ok && replyTo != "" {
+		msg.Reference = &discordgo.MessageReference{
+			MessageID: replyTo,
+			ChannelID: channelID,
+		}
+	}
+
+	if raw, ok := args["embeds"]; ok {
+		embeds, err := parseEmbeds(raw)
+		if err != nil {
+			return nil, err
+		}
+		msg.Embeds = embeds
+	}
+
+	if raw, ok := args["allowed_mentions"]; ok {
+		mentions, err := parseAllowedMentions(raw)
+		if err != nil {
+			return nil, err
+		}
+		msg.AllowedMentions = mentions
+	}
+
+	if raw, ok := args["attachments"]; ok {
+		files, err := s.parseAttachments(raw)
+		if err != nil {
+			return nil, err
+		}
+		msg.Files = files
+	}
+
+	if msg.Content == "" && len(msg.Embeds) == 0 && len(msg.Files) == 0 {
+		return nil, fmt.Errorf("content, embeds or attachments is required")
+	}
+	return msg, nil
+}
+
+func parseEmbeds(raw interface{}) ([]*discordgo.MessageEmbed, error) {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("embeds must be an array")
+	}
+	if len(items) > maxEmbeds {
+		return nil, fmt.Errorf("a message can have at most %d embeds", maxEmbeds)
+	}
+
+	embeds := make([]*discordgo.MessageEmbed, 0, len(items))
+	for i, item := range items {
+		e, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("embeds[%d] must be an object", i)
+		}
+
+		embed := &discordgo.MessageEmbed{}
+		embed.Title, _ = e["title"].(string)
+		embed.Description, _ = e["description"].(string)
+		embed.URL, _ = e["url"].(string)
+
+		if color, ok := e["color"]; ok {
+			c, err := parseColor(color)
+			if err != nil {
+				return nil, fmt.Errorf("embeds[%d].color: %w", i, err)
+			}
+			embed.Color = c
+		}
+		if footer, ok := e["footer"].(string); ok && footer != "" {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+		}
+		if image, ok := e["image_url"].(string); ok && image != "" {
+			embed.Image = &discordgo.MessageEmbedImage{URL: image}
+		}
+		if thumbnail, ok := e["thumbnail_url"].(string); ok && thumbnail != "" {
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: thumbnail}
+		}
+
+		if fields, ok := e["fields"].([]interface{}); ok {
+			for j, field := range fields {
+				f, ok := field.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("embeds[%d].fields[%d] must be an object", i, j)
+				}
+				name, _ := f["name"].(string)
+				value, _ := f["value"].(string)
+				if name == "" || value == "" {
+					return nil, fmt.Errorf("embeds[%d].fields[%d] needs a name and a value", i, j)
+				}
+				inline, _ := f["inline"].(bool)
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+					Name:   name,
+					Value:  value,
+					Inline: inline,
+				})
+			}
+		}
+
+		if embed.Title == "" && embed.Description == "" && len(embed.Fields) == 0 && embed.Image == nil {
+			return nil, fmt.Errorf("embeds[%d] needs a title, description, fields or image", i)
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds, nil
+}
+
+// parseColor accepts a color as a number or a "#rrggbb" string.
+func parseColor(raw interface{}) (int, error) {
+	switch v := raw.(type) {
+	case float64:
+		if v < 0 || v > 0xFFFFFF {
+			return 0, fmt.Errorf("must be between 0 and 0xFFFFFF")
+		}
+		return int(v), nil
+	case string:
+		c, err := strconv.ParseUint(strings.TrimPrefix(v, "#"), 16, 32)
+		if err != nil || c > 0xFFFFFF {
+			return 0, fmt.Errorf("invalid color %q", v)
+		}
+		return int(c), nil
+	default:
+		return 0, fmt.Errorf("must be a number or a \"#rrggbb\" string")
+	}
+}
+
+func parseAllowedMentions(raw interface{}) (*discordgo.MessageAllowedMentions, error) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("allowed_mentions must be an object")
+	}
+
+	mentions := &discordgo.MessageAllowedMentions{}
+	mentions.RepliedUser, _ = m["replied_user"].(bool)
+	mentions.Users = stringList(m["users"])
+	mentions.Roles = stringList(m["roles"])
+
+	parse := make(map[string]bool)
+	for _, p := range stringList(m["parse"]) {
+		switch discordgo.AllowedMentionType(p) {
+		case discordgo.AllowedMentionTypeUsers, discordgo.AllowedMentionTypeRoles, discordgo.AllowedMentionTypeEveryone:
+		default:
+			return nil, fmt.Errorf("allowed_mentions.parse: unknown mention type %q", p)
+		}
+		parse[p] = true
+		mentions.Parse = append(mentions.Parse, discordgo.AllowedMentionType(p))
+	}
+
+	// Discord rejects an explicit ID list alongside parsing the same type
+	if parse[string(discordgo.AllowedMentionTypeUsers)] && len(mentions.Users) > 0 {
+		return nil, fmt.Errorf("allowed_mentions cannot list users and also parse all users")
+	}
+	if parse[string(discordgo.AllowedMentionTypeRoles)] && len(mentions.Roles) > 0 {
+		return nil, fmt.Errorf("allowed_mentions cannot list roles and also parse all roles")
+	}
+	return mentions, nil
+}
+
+// parseAttachments reads the files to attach, each given either inline as
+// base64 or as a path inside the configured attachment directory.
+func (s *Server) parseAttachments(raw interface{}) ([]*discordgo.File, error) {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("attachments must be an array")
+	}
+	if len(items) > maxAttachments {
+		return nil, fmt.Errorf("a message can have at most %d attachments", maxAttachments)
+	}
+
+	files := make([]*discordgo.File, 0, len(items))
+	for i, item := range items {
+		a, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("attachments[%d] must be an object", i)
+		}
+
+		name, _ := a["filename"].(string)
+		encoded, _ := a["content_base64"].(string)
+		path, _ := a["path"].(string)
+
+		var data []byte
+		var err error
+		switch {
+		case encoded != "" && path != "":
+			return nil, fmt.Errorf("attachments[%d] must set only one of content_base64 and path", i)
+		case encoded != "":
+			if name == "" {
+				return nil, fmt.Errorf("attachments[%d].filename is required with content_base64", i)
+			}
+			data, err = decodeAttachment(encoded)
+		case path != "":
+			if name == "" {
+				name = filepath.Base(path)
+			}
+			data, err = s.readAttachment(path)
+		default:
+			return nil, fmt.Errorf("attachments[%d] needs content_base64 or path", i)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("attachments[%d]: %w", i, err)
+		}
+
+		name = filepath.Base(name)
+		contentType, _ := a["content_type"].(string)
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(name))
+		}
+		files = append(files, &discordgo.File{
+			Name:        name,
+			ContentType: contentType,
+			Reader:      bytes.NewReader(data),
+		})
+	}
+	return files, nil
+}
+
+func decodeAttachment(encoded string) ([]byte, error) {
+	if base64.StdEncoding.DecodedLen(len(encoded)) > maxAttachmentSize+2 {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxAttachmentSize)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+	if len(data) > maxAttachmentSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxAttachmentSize)
+	}
+	return data, nil
+}
+
+// auditArgs returns tool arguments as they are written to the audit log,
+// with each inline attachment's base64 content replaced by its size and
+// SHA-256, so files do not bloat or overflow the log. args is not modified.
+func auditArgs(args map[string]interface{}) map[string]interface{} {
+	items, ok := args["attachments"].([]interface{})
+	if !ok {
+		return args
+	}
+
+	attachments := make([]interface{}, len(items))
+	for i, item := range items {
+		a, ok := item.(map[string]interface{})
+		encoded, _ := a["content_base64"].(string)
+		if !ok || encoded == "" {
+			attachments[i] = item
+			continue
+		}
+
+		summary := make(map[string]interface{}, len(a)+1)
+		for k, v := range a {
+			if k != "content_base64" {
+				summary[k] = v
+			}
+		}
+		// Content that is not valid base64 is described as given
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			data = []byte(encoded)
+		}
+		sum := sha256.Sum256(data)
+		summary["size"] = len(data)
+		summary["sha256"] = hex.EncodeToString(sum[:])
+		attachments[i] = summary
+	}
+
+	audited := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		audited[k] = v
+	}
+	audited["attachments"] = attachments
+	return audited
+}
+
+// readAttachment reads a file from the attachment directory. Paths are
+// resolved relative to the directory, and symlinks are followed before
+// checking that the file is inside it.
+func (s *Server) readAttachment(path string) ([]byte, error) {
+	dir := s.config.Discord.AttachmentDir
+	if dir == "" {
+		return nil, fmt.Errorf("local attachments are disabled; set discord.attachment_dir")
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve attachment directory: %w", err)
+	}
+	root, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve attachment directory: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	// Refuse paths that are outside the directory as written before
+	// touching the file system, so errors cannot reveal whether files
+	// exist elsewhere, then again once symlinks are followed
+	path = filepath.Clean(path)
+	if !withinDir(root, path) && !withinDir(abs, path) {
+		return nil, fmt.Errorf("%s is outside the attachment directory", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	if !withinDir(root, resolved) {
+		return nil, fmt.Errorf("%s is outside the attachment directory", path)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > maxAttachmentSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxAttachmentSize)
+	}
+	return os.ReadFile(resolved)
+}
+
+// withinDir reports whether path names dir or something below it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// stringList returns the strings in a JSON array argument.
+func stringList(raw interface{}) []string {
+	items, _ := raw.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,342 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageTools edit and react to existing messages.
+var messageTools = []Tool{
+	{
+		Name:        "edit_message",
+		Description: "Edit the content or embeds of a message the bot sent",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel the message is in"
+				},
+				"message_id": {
+					"type": "string",
+					"description": "The ID of the message to edit"
+				},
+				"content": {
+					"type": "string",
+					"description": "New content. Omit to keep the current content"
+				},
+				"embeds": {
+					"type": "array",
+					"description": "New embeds, in the same form as send_message, replacing the current ones. Pass [] to remove them",
+					"maxItems": 10,
+					"items": {"type": "object"}
+				},
+				"allowed_mentions": {
+					"type": "object",
+					"description": "Which mentions in the new content may ping, as for send_message"
+				}
+			},
+			"required": ["channel_id", "message_id"]
+		}`),
+		OutputSchema: sendMessageOutputSchema,
+	},
+	{
+		Name:         "pin_message",
+		Description:  "Pin a message in its channel",
+		InputSchema:  messageRefSchema,
+		OutputSchema: messageActionOutputSchema,
+	},
+	{
+		Name:         "unpin_message",
+		Description:  "Unpin a message in its channel",
+		InputSchema:  messageRefSchema,
+		OutputSchema: messageActionOutputSchema,
+	},
+	{
+		Name:        "add_reaction",
+		Description: "React to a message as the bot",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel the message is in"
+				},
+				"message_id": {
+					"type": "string",
+					"description": "The ID of the message to react to"
+				},
+				"emoji": {
+					"type": "string",
+					"description": "A unicode emoji, or a custom emoji as <:name:id> or name:id"
+				}
+			},
+			"required": ["channel_id", "message_id", "emoji"]
+		}`),
+		OutputSchema: messageActionOutputSchema,
+	},
+	{
+		Name:        "remove_reaction",
+		Description: "Remove the bot's reaction from a message, or another user's with messages:manage",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel the message is in"
+				},
+				"message_id": {
+					"type": "string",
+					"description": "The ID of the message"
+				},
+				"emoji": {
+					"type": "string",
+					"description": "A unicode emoji, or a custom emoji as <:name:id> or name:id"
+				},
+				"user_id": {
+					"type": "string",
+					"description": "Remove this user's reaction instead of the bot's"
+				}
+			},
+			"required": ["channel_id", "message_id", "emoji"]
+		}`),
+		OutputSchema: messageActionOutputSchema,
+	},
+	{
+		Name:        "list_reactions",
+		Description: "List the reactions on a message, or the users who reacted with one emoji",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel the message is in"
+				},
+				"message_id": {
+					"type": "string",
+					"description": "The ID of the message"
+				},
+				"emoji": {
+					"type": "string",
+					"description": "List the users who reacted with this emoji"
+				},
+				"limit": {
+					"type": "number",
+					"description": "Maximum number of users to return (default: 25, max: 100)",
+					"default": 25
+				},
+				"after": {
+					"type": "string",
+					"description": "Only return users after this user ID, e.g. the cursor from a previous call"
+				}
+			},
+			"required": ["channel_id", "message_id"]
+		}`),
+		OutputSchema: reactionListOutputSchema,
+	},
+}
+
+var messageRefSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"channel_id": {
+			"type": "string",
+			"description": "The ID of the channel the message is in"
+		},
+		"message_id": {
+			"type": "string",
+			"description": "The ID of the message"
+		}
+	},
+	"required": ["channel_id", "message_id"]
+}`)
+
+// messageRef returns the channel_id and message_id arguments.
+func messageRef(args map[string]interface{}) (string, string, error) {
+	channelID, _ := args["channel_id"].(string)
+	if channelID == "" {
+		return "", "", fmt.Errorf("channel_id is required")
+	}
+	messageID, _ := args["message_id"].(string)
+	if messageID == "" {
+		return "", "", fmt.Errorf("message_id is required")
+	}
+	return channelID, messageID, nil
+}
+
+func (s *Server) handleEditMessage(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, messageID, err := messageRef(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	edit := discordgo.NewMessageEdit(channelID, messageID)
+	if content, ok := args["content"].(string); ok {
+		edit.Content = &content
+	}
+	if raw, ok := args["embeds"]; ok {
+		embeds, err := parseEmbeds(raw)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		edit.Embeds = &embeds
+	}
+	if edit.Content == nil && edit.Embeds == nil {
+		return CallToolResult{}, fmt.Errorf("content or embeds is required")
+	}
+
+	edit.AllowedMentions = defaultAllowedMentions()
+	if raw, ok := args["allowed_mentions"]; ok {
+		mentions, err := parseAllowedMentions(raw)
+		if err != nil {
+			return CallToolResult{}, err
+		}
+		edit.AllowedMentions = mentions
+	}
+
+	message, err := s.discordClient.EditMessage(ctx, edit)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Message %s edited successfully", message.ID),
+		SendMessageResult{Message: toDiscordMessage(message)},
+	), nil
+}
+
+func (s *Server) handlePinMessage(ctx context.Context, args map[string]interface{}, pin bool) (CallToolResult, error) {
+	channelID, messageID, err := messageRef(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	action, verb := "pin_message", "pin"
+	if pin {
+		err = s.discordClient.PinMessage(ctx, channelID, messageID)
+	} else {
+		action, verb = "unpin_message", "unpin"
+		err = s.discordClient.UnpinMessage(ctx, channelID, messageID)
+	}
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to %s message: %w", verb, err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Message %s %sned successfully", messageID, verb),
+		MessageActionResult{Action: action, ChannelID: channelID, MessageID: messageID},
+	), nil
+}
+
+func (s *Server) handleAddReaction(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, messageID, err := messageRef(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	emoji, _ := args["emoji"].(string)
+	if emoji == "" {
+		return CallToolResult{}, fmt.Errorf("emoji is required")
+	}
+
+	if err := s.discordClient.AddReaction(ctx, channelID, messageID, emoji); err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Reacted to message %s with %s", messageID, emoji),
+		MessageActionResult{Action: "add_reaction", ChannelID: channelID, MessageID: messageID, Emoji: emoji},
+	), nil
+}
+
+func (s *Server) handleRemoveReaction(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, messageID, err := messageRef(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	emoji, _ := args["emoji"].(string)
+	if emoji == "" {
+		return CallToolResult{}, fmt.Errorf("emoji is required")
+	}
+	userID, _ := args["user_id"].(string)
+
+	if err := s.discordClient.RemoveReaction(ctx, channelID, messageID, emoji, userID); err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Removed %s reaction from message %s", emoji, messageID),
+		MessageActionResult{Action: "remove_reaction", ChannelID: channelID, MessageID: messageID, Emoji: emoji, UserID: userID},
+	), nil
+}
+
+func (s *Server) handleListReactions(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, messageID, err := messageRef(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	result := ReactionListResult{ChannelID: channelID, MessageID: messageID}
+	var b strings.Builder
+
+	emoji, _ := args["emoji"].(string)
+	if emoji == "" {
+		message, err := s.discordClient.GetMessage(ctx, channelID, messageID)
+		if err != nil {
+			return CallToolResult{}, fmt.Errorf("failed to get message: %w", err)
+		}
+
+		result.Reactions = make([]ReactionCount, 0, len(message.Reactions))
+		fmt.Fprintf(&b, "Message %s has %d reactions:\n", messageID, len(message.Reactions))
+		for _, r := range message.Reactions {
+			if r.Emoji == nil {
+				continue
+			}
+			count := ReactionCount{Emoji: formatEmoji(r.Emoji), Count: r.Count, Me: r.Me}
+			result.Reactions = append(result.Reactions, count)
+			fmt.Fprintf(&b, "%s x%d\n", count.Emoji, count.Count)
+		}
+		return structuredResult(b.String(), result), nil
+	}
+
+	limit := 25
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+		if limit > 100 {
+			limit = 100
+		}
+	}
+	after, _ := args["after"].(string)
+
+	users, err := s.discordClient.GetReactionUsers(ctx, channelID, messageID, emoji, limit, after)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to list reactions: %w", err)
+	}
+
+	result.Emoji = emoji
+	result.Users = make([]DiscordUser, 0, len(users))
+	fmt.Fprintf(&b, "%d users reacted to message %s with %s:\n", len(users), messageID, emoji)
+	for _, u := range users {
+		result.Users = append(result.Users, DiscordUser{ID: u.ID, Username: u.Username, Bot: u.Bot})
+		fmt.Fprintf(&b, "%s (%s)\n", u.Username, u.ID)
+	}
+	if len(users) > 0 && len(users) == limit {
+		result.NextAfter = users[len(users)-1].ID
+		fmt.Fprintf(&b, "More users may have reacted; continue with after=%q.", result.NextAfter)
+	}
+	return structuredResult(b.String(), result), nil
+}
+
+// formatEmoji renders an emoji the way it is written in a message.
+func formatEmoji(e *discordgo.Emoji) string {
+	if e.ID == "" {
+		return e.Name
+	}
+	if e.Animated {
+		return fmt.Sprintf("<a:%s:%s>", e.Name, e.ID)
+	}
+	return fmt.Sprintf("<:%s:%s>", e.Name, e.ID)
+}
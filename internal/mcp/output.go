@@ -0,0 +1,572 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageSchema is the JSON schema of a DiscordMessage.
+const messageSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"channel_id": {"type": "string"},
+		"author": {"type": "string"},
+		"author_id": {"type": "string"},
+		"content": {"type": "string"},
+		"timestamp": {"type": "string", "format": "date-time"}
+	},
+	"required": ["id", "channel_id", "author", "content", "timestamp"]
+}`
+
+// channelSchema is the JSON schema of a DiscordChannel.
+const channelSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"name": {"type": "string"},
+		"type": {"type": "integer"},
+		"type_name": {"type": "string", "description": "text, voice, category, announcement, forum, public_thread, ..."},
+		"guild_id": {"type": "string"},
+		"position": {"type": "integer"},
+		"parent_id": {"type": "string", "description": "The category the channel is in"},
+		"topic": {"type": "string"},
+		"nsfw": {"type": "boolean"},
+		"slowmode_seconds": {"type": "integer"},
+		"permission_overwrites": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string"},
+					"type": {"type": "string", "enum": ["role", "member"]},
+					"allow": {"type": "array", "items": {"type": "string"}},
+					"deny": {"type": "array", "items": {"type": "string"}}
+				},
+				"required": ["id", "type", "allow", "deny"]
+			}
+		},
+		"available_tags": {"type": "array", "items": ` + forumTagSchema + `}
+	},
+	"required": ["id", "name", "type", "type_name"]
+}`
+
+// forumTagSchema is the JSON schema of a DiscordForumTag.
+const forumTagSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"name": {"type": "string"},
+		"moderated": {"type": "boolean"},
+		"emoji": {"type": "string"}
+	},
+	"required": ["id", "name"]
+}`
+
+// threadSchema is the JSON schema of a DiscordThread.
+const threadSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"name": {"type": "string"},
+		"parent_id": {"type": "string"},
+		"guild_id": {"type": "string"},
+		"owner_id": {"type": "string"},
+		"private": {"type": "boolean"},
+		"archived": {"type": "boolean"},
+		"locked": {"type": "boolean"},
+		"auto_archive_minutes": {"type": "integer"},
+		"archived_at": {"type": "string", "format": "date-time"},
+		"message_count": {"type": "integer"},
+		"member_count": {"type": "integer"},
+		"applied_tags": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["id", "name", "parent_id"]
+}`
+
+// userSchema is the JSON schema of a DiscordUser.
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"username": {"type": "string"},
+		"bot": {"type": "boolean"}
+	},
+	"required": ["id", "username"]
+}`
+
+// memberSchema is the JSON schema of a DiscordMember.
+const memberSchema = `{
+	"type": "object",
+	"properties": {
+		"user_id": {"type": "string"},
+		"username": {"type": "string"},
+		"global_name": {"type": "string"},
+		"nickname": {"type": "string"},
+		"display_name": {"type": "string", "description": "The name shown in the guild: nickname, else global name, else username"},
+		"bot": {"type": "boolean"},
+		"roles": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string"},
+					"name": {"type": "string"}
+				},
+				"required": ["id", "name"]
+			}
+		},
+		"joined_at": {"type": "string", "format": "date-time"},
+		"pending": {"type": "boolean", "description": "The member has not yet passed membership screening"},
+		"timed_out_until": {"type": "string", "format": "date-time", "description": "Set while the member is timed out"}
+	},
+	"required": ["user_id", "username", "display_name", "roles"]
+}`
+
+// roleSchema is the JSON schema of a DiscordRole.
+const roleSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"name": {"type": "string"},
+		"color": {"type": "string", "description": "#rrggbb, unset for the default color"},
+		"position": {"type": "integer"},
+		"permissions": {"type": "array", "items": {"type": "string"}},
+		"hoist": {"type": "boolean", "description": "Members are shown separately in the member list"},
+		"mentionable": {"type": "boolean"},
+		"managed": {"type": "boolean", "description": "The role belongs to an integration and cannot be assigned"}
+	},
+	"required": ["id", "name", "position", "permissions"]
+}`
+
+// Output schemas advertised in tools/list. Each describes the
+// structuredContent the tool returns.
+var (
+	sendMessageOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"message": ` + messageSchema + `
+		},
+		"required": ["message"]
+	}`)
+
+	messageListOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"channel_id": {"type": "string"},
+			"count": {"type": "integer"},
+			"messages": {"type": "array", "items": ` + messageSchema + `},
+			"next_before": {"type": "string", "description": "Pass as before to fetch older messages"},
+			"next_after": {"type": "string", "description": "Pass as after to fetch newer messages"}
+		},
+		"required": ["count", "messages"]
+	}`)
+
+	channelOutputSchema = json.RawMessage(channelSchema)
+
+	moderationOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string"},
+			"guild_id": {"type": "string"},
+			"channel_id": {"type": "string"},
+			"message_id": {"type": "string"},
+			"user_id": {"type": "string"},
+			"reason": {"type": "string"},
+			"until": {"type": "string", "format": "date-time", "description": "When a timeout_user timeout ends"},
+			"nickname": {"type": "string"},
+			"bans": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"user": ` + userSchema + `,
+						"reason": {"type": "string"}
+					},
+					"required": ["user"]
+				}
+			},
+			"next_after": {"type": "string", "description": "Pass as after to list more bans"},
+			"bulk_delete": {
+				"type": "object",
+				"properties": {
+					"matched": {"type": "integer"},
+					"deleted": {"type": "integer"},
+					"bulk": {"type": "integer", "description": "Deleted with the bulk delete endpoint"},
+					"single": {"type": "integer", "description": "Deleted one at a time, being over two weeks old"},
+					"failed": {"type": "integer"},
+					"failures": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"channel_id": {"type": "string"},
+								"message_id": {"type": "string"},
+								"error": {"type": "string"}
+							},
+							"required": ["channel_id", "message_id", "error"]
+						}
+					},
+					"messages": {"type": "array", "items": ` + messageSchema + `, "description": "In a dry run, the messages that would be deleted"}
+				},
+				"required": ["matched", "deleted", "bulk", "single", "failed"]
+			},
+			"dry_run": {"type": "boolean", "description": "Set when nothing was changed"},
+			"target": ` + memberSchema + `,
+			"message": ` + messageSchema + `
+		},
+		"required": ["action"]
+	}`)
+
+	messageActionOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string"},
+			"channel_id": {"type": "string"},
+			"message_id": {"type": "string"},
+			"emoji": {"type": "string"},
+			"user_id": {"type": "string"}
+		},
+		"required": ["action", "channel_id", "message_id"]
+	}`)
+
+	reactionListOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"channel_id": {"type": "string"},
+			"message_id": {"type": "string"},
+			"reactions": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"emoji": {"type": "string"},
+						"count": {"type": "integer"},
+						"me": {"type": "boolean"}
+					},
+					"required": ["emoji", "count"]
+				}
+			},
+			"emoji": {"type": "string"},
+			"users": {"type": "array", "items": ` + userSchema + `},
+			"next_after": {"type": "string", "description": "Pass as after to fetch more users"}
+		},
+		"required": ["channel_id", "message_id"]
+	}`)
+
+	channelListOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"guild_id": {"type": "string"},
+			"count": {"type": "integer"},
+			"channels": {"type": "array", "items": ` + channelSchema + `}
+		},
+		"required": ["guild_id", "count", "channels"]
+	}`)
+
+	channelActionOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string"},
+			"guild_id": {"type": "string"},
+			"channel_ids": {"type": "array", "items": {"type": "string"}},
+			"reason": {"type": "string"}
+		},
+		"required": ["action"]
+	}`)
+
+	memberOutputSchema = json.RawMessage(memberSchema)
+
+	memberListOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"guild_id": {"type": "string"},
+			"query": {"type": "string"},
+			"count": {"type": "integer"},
+			"members": {"type": "array", "items": ` + memberSchema + `},
+			"next_after": {"type": "string", "description": "Pass as after to fetch more members"}
+		},
+		"required": ["guild_id", "count", "members"]
+	}`)
+
+	roleOutputSchema = json.RawMessage(roleSchema)
+
+	roleListOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"guild_id": {"type": "string"},
+			"count": {"type": "integer"},
+			"roles": {"type": "array", "items": ` + roleSchema + `}
+		},
+		"required": ["guild_id", "count", "roles"]
+	}`)
+
+	roleActionOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string"},
+			"guild_id": {"type": "string"},
+			"role_id": {"type": "string"},
+			"user_id": {"type": "string"},
+			"reason": {"type": "string"}
+		},
+		"required": ["action", "guild_id", "role_id"]
+	}`)
+
+	threadOutputSchema = json.RawMessage(threadSchema)
+
+	threadListOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"channel_id": {"type": "string"},
+			"archived": {"type": "boolean"},
+			"count": {"type": "integer"},
+			"threads": {"type": "array", "items": ` + threadSchema + `},
+			"next_before": {"type": "string", "description": "Pass as before to fetch threads archived earlier"}
+		},
+		"required": ["channel_id", "count", "threads"]
+	}`)
+
+	threadActionOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string"},
+			"thread_id": {"type": "string"}
+		},
+		"required": ["action", "thread_id"]
+	}`)
+
+	searchIndexOutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string"},
+			"count": {"type": "integer"},
+			"results": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"message": ` + messageSchema + `,
+						"score": {"type": "number"}
+					},
+					"required": ["message", "score"]
+				}
+			}
+		},
+		"required": ["query", "count", "results"]
+	}`)
+)
+
+// SendMessageResult is the structured result of send_message.
+type SendMessageResult struct {
+	Message DiscordMessage `json:"message"`
+}
+
+// MessageListResult is the structured result of tools returning messages.
+type MessageListResult struct {
+	ChannelID  string           `json:"channel_id,omitempty"`
+	Count      int              `json:"count"`
+	Messages   []DiscordMessage `json:"messages"`
+	NextBefore string           `json:"next_before,omitempty"`
+	NextAfter  string           `json:"next_after,omitempty"`
+}
+
+// ModerationResult is the structured result of moderate_content.
+type ModerationResult struct {
+	Action    string `json:"action"`
+	GuildID   string `json:"guild_id,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Until     string `json:"until,omitempty"`
+
+	// Nickname is set by set_nickname, and empty if it was reset.
+	Nickname *string `json:"nickname,omitempty"`
+
+	// Bans and NextAfter are set by list_bans.
+	Bans      []DiscordBan `json:"bans,omitempty"`
+	NextAfter string       `json:"next_after,omitempty"`
+
+	BulkDelete *BulkDeleteSummary `json:"bulk_delete,omitempty"`
+
+	// DryRun is set when nothing was changed. Target is the member the
+	// action would have acted on and Message the message delete_message
+	// would have deleted.
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Target  *DiscordMember  `json:"target,omitempty"`
+	Message *DiscordMessage `json:"message,omitempty"`
+}
+
+// BulkDeleteSummary counts the messages bulk_delete matched and deleted.
+// Bulk were deleted with Discord's bulk endpoint, Single one at a time
+// because they were over two weeks old. In a dry run nothing is deleted,
+// Bulk and Single count what would be and Messages lists the matches.
+type BulkDeleteSummary struct {
+	Matched  int                 `json:"matched"`
+	Deleted  int                 `json:"deleted"`
+	Bulk     int                 `json:"bulk"`
+	Single   int                 `json:"single"`
+	Failed   int                 `json:"failed"`
+	Failures []BulkDeleteFailure `json:"failures,omitempty"`
+	Messages []DiscordMessage    `json:"messages,omitempty"`
+}
+
+type BulkDeleteFailure struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	Error     string `json:"error"`
+}
+
+// MessageActionResult is the structured result of tools acting on a single
+// message, such as pin_message and add_reaction.
+type MessageActionResult struct {
+	Action    string `json:"action"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	Emoji     string `json:"emoji,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+}
+
+// ReactionListResult is the structured result of list_reactions. Reactions
+// is set when listing every reaction on the message, Users when listing
+// who reacted with Emoji.
+type ReactionListResult struct {
+	ChannelID string          `json:"channel_id"`
+	MessageID string          `json:"message_id"`
+	Reactions []ReactionCount `json:"reactions,omitempty"`
+	Emoji     string          `json:"emoji,omitempty"`
+	Users     []DiscordUser   `json:"users,omitempty"`
+	NextAfter string          `json:"next_after,omitempty"`
+}
+
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+	Me    bool   `json:"me,omitempty"`
+}
+
+// ChannelListResult is the structured result of list_channels.
+type ChannelListResult struct {
+	GuildID  string           `json:"guild_id"`
+	Count    int              `json:"count"`
+	Channels []DiscordChannel `json:"channels"`
+}
+
+// ChannelActionResult is the structured result of delete_channel and
+// reorder_channels.
+type ChannelActionResult struct {
+	Action     string   `json:"action"`
+	GuildID    string   `json:"guild_id,omitempty"`
+	ChannelIDs []string `json:"channel_ids,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// MemberListResult is the structured result of list_members and
+// search_members.
+type MemberListResult struct {
+	GuildID   string          `json:"guild_id"`
+	Query     string          `json:"query,omitempty"`
+	Count     int             `json:"count"`
+	Members   []DiscordMember `json:"members"`
+	NextAfter string          `json:"next_after,omitempty"`
+}
+
+// RoleListResult is the structured result of list_roles.
+type RoleListResult struct {
+	GuildID string        `json:"guild_id"`
+	Count   int           `json:"count"`
+	Roles   []DiscordRole `json:"roles"`
+}
+
+// RoleActionResult is the structured result of delete_role,
+// add_member_role and remove_member_role.
+type RoleActionResult struct {
+	Action  string `json:"action"`
+	GuildID string `json:"guild_id"`
+	RoleID  string `json:"role_id"`
+	UserID  string `json:"user_id,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ThreadListResult is the structured result of list_threads.
+type ThreadListResult struct {
+	ChannelID  string          `json:"channel_id"`
+	Archived   bool            `json:"archived"`
+	Count      int             `json:"count"`
+	Threads    []DiscordThread `json:"threads"`
+	NextBefore string          `json:"next_before,omitempty"`
+}
+
+// ThreadActionResult is the structured result of join_thread and
+// leave_thread.
+type ThreadActionResult struct {
+	Action   string `json:"action"`
+	ThreadID string `json:"thread_id"`
+}
+
+// IndexSearchResult is the structured result of search_index.
+type IndexSearchResult struct {
+	Query   string             `json:"query"`
+	Count   int                `json:"count"`
+	Results []IndexSearchMatch `json:"results"`
+}
+
+type IndexSearchMatch struct {
+	Message DiscordMessage `json:"message"`
+	Score   float64        `json:"score"`
+}
+
+// structuredResult returns a tool result carrying both a text rendering
+// and the structured value it was rendered from.
+func structuredResult(text string, structured interface{}) CallToolResult {
+	return CallToolResult{
+		Content: []ToolContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		StructuredContent: structured,
+	}
+}
+
+func toDiscordMessage(msg *discordgo.Message) DiscordMessage {
+	m := DiscordMessage{
+		ID:        msg.ID,
+		ChannelID: msg.ChannelID,
+		Content:   msg.Content,
+		Timestamp: msg.Timestamp,
+	}
+	if msg.Author != nil {
+		m.Author = msg.Author.Username
+		m.AuthorID = msg.Author.ID
+	}
+	return m
+}
+
+func toDiscordMessages(messages []*discordgo.Message) []DiscordMessage {
+	converted := make([]DiscordMessage, 0, len(messages))
+	for _, msg := range messages {
+		converted = append(converted, toDiscordMessage(msg))
+	}
+	return converted
+}
+
+// formatMessageLines renders messages one per line in the order given. If
+// withChannel is set each line also names the message's channel.
+func formatMessageLines(messages []DiscordMessage, withChannel bool) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "[%s] ", msg.Timestamp.Format(time.RFC3339))
+		if withChannel {
+			fmt.Fprintf(&b, "<#%s> ", msg.ChannelID)
+		}
+		fmt.Fprintf(&b, "%s: %s\n", msg.Author, msg.Content)
+	}
+	return b.String()
+}
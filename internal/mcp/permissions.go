@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/ReesavGupta/discord-mcp-server/pkg/utils"
+)
+
+// toolPermissions maps each tool to the permission a caller needs to invoke
+// it. moderate_content is checked per action instead, see
+// moderationPermissions, and removing another user's reaction needs
+// messages:manage.
+var toolPermissions = map[string]string{
+	"send_message":       "messages:write",
+	"get_messages":       "messages:read",
+	"get_channel_info":   "channels:read",
+	"search_messages":    "messages:read",
+	"search_index":       "messages:read",
+	"edit_message":       "messages:write",
+	"pin_message":        "messages:manage",
+	"unpin_message":      "messages:manage",
+	"add_reaction":       "messages:write",
+	"remove_reaction":    "messages:write",
+	"list_reactions":     "messages:read",
+	"create_thread":      "threads:write",
+	"list_threads":       "threads:read",
+	"join_thread":        "threads:write",
+	"leave_thread":       "threads:write",
+	"archive_thread":     "threads:manage",
+	"list_forum_posts":   "threads:read",
+	"create_forum_post":  "threads:write",
+	"manage_forum_tags":  "channels:manage",
+	"list_channels":      "channels:read",
+	"create_channel":     "channels:manage",
+	"edit_channel":       "channels:manage",
+	"delete_channel":     "channels:manage",
+	"reorder_channels":   "channels:manage",
+	"list_members":       "members:read",
+	"search_members":     "members:read",
+	"get_member":         "members:read",
+	"list_roles":         "roles:read",
+	"create_role":        "roles:manage",
+	"edit_role":          "roles:manage",
+	"delete_role":        "roles:manage",
+	"add_member_role":    "roles:assign",
+	"remove_member_role": "roles:assign",
+}
+
+// moderationPermissions maps each moderate_content action to the permission
+// it needs.
+var moderationPermissions = map[string]string{
+	"delete_message": "moderation:delete",
+	"bulk_delete":    "moderation:delete",
+	"kick_user":      "moderation:kick",
+	"ban_user":       "moderation:ban",
+	"unban_user":     "moderation:ban",
+	"list_bans":      "moderation:ban",
+	"timeout_user":   "moderation:timeout",
+	"remove_timeout": "moderation:timeout",
+	"set_nickname":   "moderation:nickname",
+}
+
+// resourcePermissions maps each kind of resource to the permission needed
+// to list or read it.
+var resourcePermissions = map[string]string{
+	resourceGuild:    "channels:read",
+	resourceChannel:  "channels:read",
+	resourceMessages: "messages:read",
+}
+
+// requiredPermission returns the permission needed to call tool with args.
+// It returns false for a tool with no permission mapping, which no caller
+// may call.
+func requiredPermission(tool string, args map[string]interface{}) (string, bool) {
+	if tool == "moderate_content" {
+		action, _ := args["action"].(string)
+		if permission, ok := moderationPermissions[action]; ok {
+			return permission, true
+		}
+		// Unknown actions are rejected by the handler itself.
+		return "moderation:*", true
+	}
+	if tool == "remove_reaction" {
+		if userID, _ := args["user_id"].(string); userID != "" {
+			return "messages:manage", true
+		}
+	}
+	permission, ok := toolPermissions[tool]
+	return permission, ok
+}
+
+// canUseTool reports whether claims allow at least some use of tool, and so
+// whether it should be advertised in tools/list.
+func canUseTool(claims *auth.Claims, tool string) bool {
+	if roleGatedTools[tool] && claims.Method == auth.MethodAnonymous {
+		return false
+	}
+	if tool == "moderate_content" {
+		for _, permission := range moderationPermissions {
+			if claims.HasPermission(permission) {
+				return true
+			}
+		}
+		return false
+	}
+
+	permission, ok := toolPermissions[tool]
+	return ok && claims.HasPermission(permission)
+}
+
+// roleGatedTools are the tools that additionally require the caller to hold
+// one of discord.allowed_roles in the target guild. Anonymous callers may
+// never use them.
+var roleGatedTools = map[string]bool{
+	"moderate_content":   true,
+	"create_channel":     true,
+	"edit_channel":       true,
+	"delete_channel":     true,
+	"reorder_channels":   true,
+	"manage_forum_tags":  true,
+	"create_role":        true,
+	"edit_role":          true,
+	"delete_role":        true,
+	"add_member_role":    true,
+	"remove_member_role": true,
+	// The index may hold messages from any guild the bot is in, so a
+	// search is held to the guild it targets
+	"search_index": true,
+}
+
+// checkAllowedRoles verifies that a JWT caller, whose user_id is taken to be
+// their Discord user ID, holds one of the configured allowed roles in the
+// guild the tool call targets. API key and stdio callers have no Discord
+// identity and are governed by their permissions alone.
+func (s *Server) checkAllowedRoles(ctx context.Context, claims *auth.Claims, tool string, args map[string]interface{}) error {
+	if roleGatedTools[tool] && claims.Method == auth.MethodAnonymous {
+		return fmt.Errorf("%s requires authentication", tool)
+	}
+
+	allowedRoles := s.config.Discord.AllowedRoles
+	if !roleGatedTools[tool] || len(allowedRoles) == 0 || claims.Method != auth.MethodJWT {
+		return nil
+	}
+
+	if !utils.ValidateDiscordID(claims.UserID) {
+		return fmt.Errorf("caller %q is not mapped to a Discord member", claims.UserID)
+	}
+
+	guildIDs, err := s.targetGuilds(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	for _, guildID := range guildIDs {
+		ok, err := s.discordClient.MemberHasAnyRole(ctx, guildID, claims.UserID, allowedRoles)
+		if err != nil {
+			return fmt.Errorf("failed to resolve roles for %s: %w", claims.UserID, err)
+		}
+		if !ok {
+			return fmt.Errorf("one of roles %s is required in guild %s",
+				strings.Join(allowedRoles, ", "), guildID)
+		}
+	}
+	return nil
+}
+
+// actingMember returns the Discord user ID of the member a call is made for,
+// or "" if the caller is not mapped to one. Like checkAllowedRoles, it takes
+// a JWT caller's user_id to be their Discord user ID when allowed_roles is
+// set. Role changes are then held to the member's own place in the role
+// hierarchy as well as the bot's.
+func (s *Server) actingMember(claims *auth.Claims) string {
+	if len(s.config.Discord.AllowedRoles) == 0 || claims.Method != auth.MethodJWT {
+		return ""
+	}
+	return claims.UserID
+}
+
+// targetGuilds works out which guilds a tool call acts on, from its
+// guild_id, channel_id and channel_ids arguments, falling back to the
+// configured guild. Every channel is resolved so a caller cannot name a
+// guild they hold a role in alongside channels from another.
+func (s *Server) targetGuilds(ctx context.Context, args map[string]interface{}) ([]string, error) {
+	var guildIDs []string
+	if guildID, ok := args["guild_id"].(string); ok && guildID != "" {
+		guildIDs = append(guildIDs, guildID)
+	}
+
+	channelIDs := stringList(args["channel_ids"])
+	if channelID, ok := args["channel_id"].(string); ok && channelID != "" {
+		channelIDs = append([]string{channelID}, channelIDs...)
+	}
+	for _, channelID := range channelIDs {
+		channel, err := s.discordClient.GetChannelInfo(ctx, channelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve guild for channel %s: %w", channelID, err)
+		}
+		if channel.GuildID != "" && !slices.Contains(guildIDs, channel.GuildID) {
+			guildIDs = append(guildIDs, channel.GuildID)
+		}
+	}
+
+	if len(guildIDs) == 0 && s.config.Discord.GuildID != "" {
+		guildIDs = append(guildIDs, s.config.Discord.GuildID)
+	}
+	if len(guildIDs) == 0 {
+		return nil, fmt.Errorf("could not determine the target guild")
+	}
+	return guildIDs, nil
+}
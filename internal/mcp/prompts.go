@@ -0,0 +1,259 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/config"
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/ReesavGupta/discord-mcp-server/pkg/utils"
+)
+
+// promptHistoryLimit caps how many messages a prompt embeds.
+const promptHistoryLimit = 100
+
+// promptDefinition is a prompt together with the template that renders it.
+type promptDefinition struct {
+	prompt         Prompt
+	template       *template.Template
+	includeHistory bool
+}
+
+var timeRangeArguments = []config.PromptArgumentConfig{
+	{Name: "since", Description: "Only include messages after this time (ISO 8601, or a duration such as 24h)"},
+	{Name: "until", Description: "Only include messages before this time (ISO 8601, or a duration such as 1h)"},
+}
+
+var builtinPrompts = []config.PromptConfig{
+	{
+		Name:        "summarize_channel",
+		Description: "Summarize recent discussion in a channel",
+		Arguments: append([]config.PromptArgumentConfig{
+			{Name: "channel_id", Description: "The channel to summarize", Required: true},
+		}, timeRangeArguments...),
+		Template: `Summarize the discussion in the Discord channel history below` +
+			`{{if .since}} since {{.since}}{{end}}{{if .until}} until {{.until}}{{end}}. ` +
+			`Highlight key decisions, open questions and action items, and note who raised each one.`,
+		IncludeHistory: true,
+	},
+	{
+		Name:        "triage_reports",
+		Description: "Triage user reports posted in a channel",
+		Arguments: append([]config.PromptArgumentConfig{
+			{Name: "channel_id", Description: "The channel reports are posted in", Required: true},
+		}, timeRangeArguments...),
+		Template: `The Discord messages below are user reports` +
+			`{{if .since}} since {{.since}}{{end}}{{if .until}} until {{.until}}{{end}}. ` +
+			`Group them by issue and rate each issue's severity (low, medium or high). ` +
+			`List duplicates together, and flag anything that needs moderator action with the message IDs involved.`,
+		IncludeHistory: true,
+	},
+	{
+		Name:        "draft_announcement",
+		Description: "Draft an announcement for a Discord channel",
+		Arguments: append([]config.PromptArgumentConfig{
+			{Name: "topic", Description: "What the announcement is about", Required: true},
+			{Name: "audience", Description: "Who the announcement is for"},
+			{Name: "channel_id", Description: "Channel whose recent messages set the tone"},
+		}, timeRangeArguments...),
+		Template: `Draft a Discord announcement about {{.topic}}` +
+			`{{if .audience}} for {{.audience}}{{end}}. ` +
+			`Keep it concise, use Discord markdown, and avoid @everyone or @here mentions.` +
+			`{{if .channel_id}} Match the tone of the recent channel messages below.{{end}}`,
+		IncludeHistory: true,
+	},
+}
+
+// loadPrompts builds the prompt registry from the built-in prompts and any
+// defined in the configuration. A configured prompt replaces a built-in one
+// of the same name.
+func loadPrompts(configured []config.PromptConfig) ([]promptDefinition, error) {
+	var prompts []promptDefinition
+	index := make(map[string]int)
+
+	for _, pc := range append(append([]config.PromptConfig{}, builtinPrompts...), configured...) {
+		if pc.Name == "" {
+			return nil, fmt.Errorf("prompt is missing a name")
+		}
+
+		tmpl, err := template.New(pc.Name).Option("missingkey=zero").Parse(pc.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for prompt %s: %w", pc.Name, err)
+		}
+
+		def := promptDefinition{
+			prompt: Prompt{
+				Name:        pc.Name,
+				Description: pc.Description,
+			},
+			template:       tmpl,
+			includeHistory: pc.IncludeHistory,
+		}
+		for _, arg := range pc.Arguments {
+			def.prompt.Arguments = append(def.prompt.Arguments, PromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
+		}
+
+		if i, ok := index[pc.Name]; ok {
+			prompts[i] = def
+			continue
+		}
+		index[pc.Name] = len(prompts)
+		prompts = append(prompts, def)
+	}
+
+	return prompts, nil
+}
+
+func (s *Server) findPrompt(name string) (promptDefinition, bool) {
+	for _, def := range s.prompts {
+		if def.prompt.Name == name {
+			return def, true
+		}
+	}
+	return promptDefinition{}, false
+}
+
+func (s *Server) handlePromptsList(w messageWriter, request JSONRPCRequest) error {
+	prompts := make([]Prompt, 0, len(s.prompts))
+	for _, def := range s.prompts {
+		prompts = append(prompts, def.prompt)
+	}
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: ListPromptsResult{
+			Prompts: prompts,
+		},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+func (s *Server) handlePromptsGet(ctx context.Context, sess *session, w messageWriter, request JSONRPCRequest) error {
+	params, ok := request.Params.(map[string]interface{})
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, "Invalid parameters")
+		return nil
+	}
+
+	name, ok := params["name"].(string)
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, "name is required")
+		return nil
+	}
+
+	def, ok := s.findPrompt(name)
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, fmt.Sprintf("Unknown prompt: %s", name))
+		return nil
+	}
+
+	args := make(map[string]string)
+	if rawArgs, ok := params["arguments"].(map[string]interface{}); ok {
+		for key, value := range rawArgs {
+			args[key] = fmt.Sprint(value)
+		}
+	}
+
+	for _, arg := range def.prompt.Arguments {
+		if arg.Required && args[arg.Name] == "" {
+			s.sendError(w, request.ID, InvalidParams, fmt.Sprintf("%s is required", arg.Name))
+			return nil
+		}
+	}
+
+	var text strings.Builder
+	if err := def.template.Execute(&text, args); err != nil {
+		return fmt.Errorf("failed to render prompt %s: %w", name, err)
+	}
+
+	messages := []PromptMessage{
+		{
+			Role:    "user",
+			Content: PromptContent{Type: "text", Text: text.String()},
+		},
+	}
+
+	if channelID := args["channel_id"]; def.includeHistory && channelID != "" {
+		claims := sess.caller()
+		if permission := resourcePermissions[resourceMessages]; claims == nil || !claims.HasPermission(permission) {
+			s.sendError(w, request.ID, Forbidden, fmt.Sprintf("permission %s required", permission))
+			return nil
+		}
+
+		history, err := s.promptHistory(ctx, channelID, args["since"], args["until"])
+		if err != nil {
+			s.sendError(w, request.ID, InvalidParams, err.Error())
+			return nil
+		}
+
+		messages = append(messages, PromptMessage{
+			Role: "user",
+			Content: PromptContent{
+				Type:     "resource",
+				Resource: history,
+			},
+		})
+	}
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: GetPromptResult{
+			Description: def.prompt.Description,
+			Messages:    messages,
+		},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+// promptHistory fetches a channel's messages within the given time range,
+// rendered as a resource to embed in a prompt.
+func (s *Server) promptHistory(ctx context.Context, channelID, since, until string) (*ResourceContents, error) {
+	filter := discord.MessageFilter{
+		ChannelID: channelID,
+		Limit:     promptHistoryLimit,
+	}
+
+	var err error
+	if since != "" {
+		if filter.After, err = parseTimeArgument(since); err != nil {
+			return nil, err
+		}
+	}
+	if until != "" {
+		if filter.Before, err = parseTimeArgument(until); err != nil {
+			return nil, err
+		}
+	}
+
+	messages, err := s.discordClient.SearchMessages(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channel history: %w", err)
+	}
+
+	return &ResourceContents{
+		URI:      messagesURI(channelID),
+		MimeType: "text/plain",
+		Text:     formatMessageHistory(messages),
+	}, nil
+}
+
+// parseTimeArgument accepts an absolute timestamp or a duration, which is
+// taken to mean that long ago.
+func parseTimeArgument(value string) (*time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		t := time.Now().Add(-d)
+		return &t, nil
+	}
+	return utils.ParseTimeFilter(value)
+}
@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	resourceGuild    = "guild"
+	resourceChannel  = "channel"
+	resourceMessages = "messages"
+
+	defaultResourceMessageLimit = 50
+)
+
+var resourceTemplates = []ResourceTemplate{
+	{
+		URITemplate: "discord://guild/{guild_id}",
+		Name:        "Discord guild",
+		Description: "A guild and its channels",
+		MimeType:    "application/json",
+	},
+	{
+		URITemplate: "discord://channel/{channel_id}",
+		Name:        "Discord channel",
+		Description: "Information about a channel",
+		MimeType:    "application/json",
+	},
+	{
+		URITemplate: "discord://channel/{channel_id}/messages",
+		Name:        "Discord channel history",
+		Description: "Recent messages in a channel, oldest first (optional ?limit=, max 100)",
+		MimeType:    "text/plain",
+	},
+}
+
+// resourceRef is a parsed discord:// resource URI.
+type resourceRef struct {
+	kind  string
+	id    string
+	query url.Values
+}
+
+func parseResourceURI(uri string) (resourceRef, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "discord" {
+		return resourceRef{}, fmt.Errorf("unsupported resource URI: %s", uri)
+	}
+
+	// In discord://channel/123/messages the host is "channel" and the path
+	// holds the rest.
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case u.Host == resourceGuild && len(parts) == 1 && parts[0] != "":
+		return resourceRef{kind: resourceGuild, id: parts[0], query: u.Query()}, nil
+	case u.Host == resourceChannel && len(parts) == 1 && parts[0] != "":
+		return resourceRef{kind: resourceChannel, id: parts[0], query: u.Query()}, nil
+	case u.Host == resourceChannel && len(parts) == 2 && parts[1] == resourceMessages:
+		return resourceRef{kind: resourceMessages, id: parts[0], query: u.Query()}, nil
+	}
+	return resourceRef{}, fmt.Errorf("unknown resource: %s", uri)
+}
+
+func guildURI(id string) string    { return "discord://guild/" + id }
+func channelURI(id string) string  { return "discord://channel/" + id }
+func messagesURI(id string) string { return "discord://channel/" + id + "/messages" }
+
+func (s *Server) handleResourcesList(ctx context.Context, sess *session, w messageWriter, request JSONRPCRequest) error {
+	claims := sess.caller()
+	canReadChannels := claims != nil && claims.HasPermission(resourcePermissions[resourceChannel])
+	canReadMessages := claims != nil && claims.HasPermission(resourcePermissions[resourceMessages])
+
+	resources := []Resource{}
+	if canReadChannels || canReadMessages {
+		guildIDs, err := s.resourceGuildIDs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list guilds: %w", err)
+		}
+
+		for _, guildID := range guildIDs {
+			channels, err := s.discordClient.GetGuildChannels(ctx, guildID)
+			if err != nil {
+				return fmt.Errorf("failed to list channels for guild %s: %w", guildID, err)
+			}
+
+			if canReadChannels {
+				resources = append(resources, Resource{
+					URI:      guildURI(guildID),
+					Name:     "Guild " + guildID,
+					MimeType: "application/json",
+				})
+			}
+
+			for _, channel := range channels {
+				if channel.Type == discordgo.ChannelTypeGuildCategory {
+					continue
+				}
+				if canReadChannels {
+					resources = append(resources, Resource{
+						URI:      channelURI(channel.ID),
+						Name:     "#" + channel.Name,
+						MimeType: "application/json",
+					})
+				}
+				if canReadMessages && discord.HasMessageHistory(channel) {
+					resources = append(resources, Resource{
+						URI:         messagesURI(channel.ID),
+						Name:        "#" + channel.Name + " history",
+						Description: "Recent messages in #" + channel.Name,
+						MimeType:    "text/plain",
+					})
+				}
+			}
+		}
+	}
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: ListResourcesResult{
+			Resources: resources,
+		},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+func (s *Server) handleResourceTemplatesList(w messageWriter, request JSONRPCRequest) error {
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: ListResourceTemplatesResult{
+			ResourceTemplates: resourceTemplates,
+		},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+func (s *Server) handleResourcesRead(ctx context.Context, sess *session, w messageWriter, request JSONRPCRequest) error {
+	params, ok := request.Params.(map[string]interface{})
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, "Invalid parameters")
+		return nil
+	}
+
+	uri, ok := params["uri"].(string)
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, "uri is required")
+		return nil
+	}
+
+	ref, err := parseResourceURI(uri)
+	if err != nil {
+		s.sendError(w, request.ID, ResourceNotFound, err.Error())
+		return nil
+	}
+
+	claims := sess.caller()
+	if permission := resourcePermissions[ref.kind]; claims == nil || !claims.HasPermission(permission) {
+		s.sendError(w, request.ID, Forbidden, fmt.Sprintf("permission %s required", permission))
+		return nil
+	}
+	s.authManager.Auditor().LogOperation("resource_read", claims.UserID, map[string]interface{}{"uri": uri})
+
+	var contents ResourceContents
+	switch ref.kind {
+	case resourceGuild:
+		contents, err = s.readGuildResource(ctx, ref)
+	case resourceChannel:
+		contents, err = s.readChannelResource(ctx, ref)
+	case resourceMessages:
+		contents, err = s.readMessagesResource(ctx, ref)
+	}
+	if discord.IsNotFound(err) {
+		s.sendError(w, request.ID, ResourceNotFound, fmt.Sprintf("resource not found: %s", uri))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	contents.URI = uri
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: ReadResourceResult{
+			Contents: []ResourceContents{contents},
+		},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+func (s *Server) readGuildResource(ctx context.Context, ref resourceRef) (ResourceContents, error) {
+	guild, err := s.discordClient.GetGuildInfo(ctx, ref.id)
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("failed to get guild info: %w", err)
+	}
+
+	channels, err := s.discordClient.GetGuildChannels(ctx, ref.id)
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("failed to get guild channels: %w", err)
+	}
+
+	info := DiscordGuild{
+		ID:          guild.ID,
+		Name:        guild.Name,
+		MemberCount: guild.ApproximateMemberCount,
+		Channels:    make([]DiscordChannel, 0, len(channels)),
+	}
+	for _, channel := range channels {
+		info.Channels = append(info.Channels, toDiscordChannel(channel))
+	}
+
+	return jsonContents(info)
+}
+
+func (s *Server) readChannelResource(ctx context.Context, ref resourceRef) (ResourceContents, error) {
+	channel, err := s.discordClient.GetChannelInfo(ctx, ref.id)
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("failed to get channel info: %w", err)
+	}
+
+	return jsonContents(toDiscordChannel(channel))
+}
+
+func (s *Server) readMessagesResource(ctx context.Context, ref resourceRef) (ResourceContents, error) {
+	limit := defaultResourceMessageLimit
+	if l, err := strconv.Atoi(ref.query.Get("limit")); err == nil && l > 0 {
+		limit = l
+		if limit > 100 {
+			limit = 100
+		}
+	}
+
+	messages, _, err := s.discordClient.GetMessages(ctx, ref.id, discord.HistoryOptions{Limit: limit})
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	return ResourceContents{MimeType: "text/plain", Text: formatMessageHistory(messages)}, nil
+}
+
+// formatMessageHistory renders messages one per line, oldest first.
+// Discord returns the newest message first.
+func formatMessageHistory(messages []*discordgo.Message) string {
+	var b strings.Builder
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		fmt.Fprintf(&b, "[%s] %s: %s\n", msg.Timestamp.Format(time.RFC3339), msg.Author.Username, msg.Content)
+	}
+	return b.String()
+}
+
+// resourceGuildIDs returns the guilds whose resources are listed: the
+// configured guild if there is one, otherwise every guild the bot is in.
+func (s *Server) resourceGuildIDs(ctx context.Context) ([]string, error) {
+	if s.config.Discord.GuildID != "" {
+		return []string{s.config.Discord.GuildID}, nil
+	}
+
+	guilds, err := s.discordClient.GetGuilds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(guilds))
+	for _, guild := range guilds {
+		ids = append(ids, guild.ID)
+	}
+	return ids, nil
+}
+
+func toDiscordChannel(channel *discordgo.Channel) DiscordChannel {
+	c := DiscordChannel{
+		ID:       channel.ID,
+		Name:     channel.Name,
+		Type:     int(channel.Type),
+		TypeName: discord.ChannelTypeName(channel.Type),
+		GuildID:  channel.GuildID,
+		Position: channel.Position,
+		ParentID: channel.ParentID,
+		Topic:    channel.Topic,
+		NSFW:     channel.NSFW,
+		Slowmode: channel.RateLimitPerUser,
+	}
+	for _, o := range channel.PermissionOverwrites {
+		overwrite := DiscordOverwrite{
+			ID:    o.ID,
+			Type:  "role",
+			Allow: discord.PermissionNames(o.Allow),
+			Deny:  discord.PermissionNames(o.Deny),
+		}
+		if o.Type == discordgo.PermissionOverwriteTypeMember {
+			overwrite.Type = "member"
+		}
+		c.PermissionOverwrites = append(c.PermissionOverwrites, overwrite)
+	}
+	for _, tag := range channel.AvailableTags {
+		c.AvailableTags = append(c.AvailableTags, DiscordForumTag{
+			ID:        tag.ID,
+			Name:      tag.Name,
+			Moderated: tag.Moderated,
+			Emoji:     tag.EmojiName,
+		})
+	}
+	return c
+}
+
+func jsonContents(v interface{}) (ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ResourceContents{}, err
+	}
+	return ResourceContents{MimeType: "application/json", Text: string(data)}, nil
+}
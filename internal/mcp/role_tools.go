@@ -0,0 +1,364 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// roleTools list and manage a guild's roles and who holds them. The bot
+// refuses changes to roles at or above its own highest role, and grants of
+// permissions it does not hold, before Discord would.
+var roleTools = []Tool{
+	{
+		Name:        "list_roles",
+		Description: "List a guild's roles from highest to lowest, with their permissions",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				}
+			}
+		}`),
+		OutputSchema: roleListOutputSchema,
+	},
+	{
+		Name:        "create_role",
+		Description: "Create a role in a guild",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},` + roleSettingsProperties + `,
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["name"]
+		}`),
+		OutputSchema: roleOutputSchema,
+	},
+	{
+		Name:        "edit_role",
+		Description: "Change a role's name, color, permissions, hoist or mentionable setting",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"role": {
+					"type": "string",
+					"description": "The role's ID or name"
+				},` + roleSettingsProperties + `,
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["role"]
+		}`),
+		OutputSchema: roleOutputSchema,
+	},
+	{
+		Name:        "delete_role",
+		Description: "Delete a role. This cannot be undone",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"guild_id": {
+					"type": "string",
+					"description": "The ID of the guild (defaults to the configured guild)"
+				},
+				"role": {
+					"type": "string",
+					"description": "The role's ID or name"
+				},
+				"reason": {
+					"type": "string",
+					"description": "Reason recorded in the audit log"
+				}
+			},
+			"required": ["role"]
+		}`),
+		OutputSchema: roleActionOutputSchema,
+	},
+	{
+		Name:         "add_member_role",
+		Description:  "Give a member a role",
+		InputSchema:  memberRoleSchema,
+		OutputSchema: roleActionOutputSchema,
+	},
+	{
+		Name:         "remove_member_role",
+		Description:  "Take a role from a member",
+		InputSchema:  memberRoleSchema,
+		OutputSchema: roleActionOutputSchema,
+	},
+}
+
+// roleSettingsProperties are the input properties shared by create_role
+// and edit_role.
+const roleSettingsProperties = `
+				"name": {
+					"type": "string",
+					"description": "The role's name"
+				},
+				"color": {
+					"type": ["string", "number"],
+					"description": "The role's color as \"#rrggbb\" or a number, 0 for the default color"
+				},
+				"permissions": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "The role's complete set of permissions by name, e.g. [\"send_messages\", \"manage_messages\"]"
+				},
+				"hoist": {
+					"type": "boolean",
+					"description": "Show members with the role separately in the member list"
+				},
+				"mentionable": {
+					"type": "boolean",
+					"description": "Let anyone mention the role"
+				}`
+
+var memberRoleSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"guild_id": {
+			"type": "string",
+			"description": "The ID of the guild (defaults to the configured guild)"
+		},
+		"user_id": {
+			"type": "string",
+			"description": "The member's user ID, or a mention such as <@123>"
+		},
+		"role": {
+			"type": "string",
+			"description": "The role's ID or name"
+		},
+		"reason": {
+			"type": "string",
+			"description": "Reason recorded in the audit log"
+		}
+	},
+	"required": ["user_id", "role"]
+}`)
+
+func (s *Server) handleListRoles(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+
+	roles, err := s.discordClient.GetGuildRoles(ctx, guildID)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to get guild roles: %w", err)
+	}
+	sort.SliceStable(roles, func(i, j int) bool {
+		return roles[i].Position > roles[j].Position
+	})
+
+	result := RoleListResult{
+		GuildID: guildID,
+		Count:   len(roles),
+		Roles:   make([]DiscordRole, 0, len(roles)),
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d roles in guild %s:\n", len(roles), guildID)
+	for _, role := range roles {
+		r := toDiscordRole(role)
+		result.Roles = append(result.Roles, r)
+
+		fmt.Fprintf(&b, "%s (%s), position %d", r.Name, r.ID, r.Position)
+		if r.Managed {
+			b.WriteString(", managed")
+		}
+		fmt.Fprintf(&b, ": %d permissions\n", len(r.Permissions))
+	}
+	return structuredResult(b.String(), result), nil
+}
+
+func (s *Server) handleCreateRole(ctx context.Context, claims *auth.Claims, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	if name, _ := args["name"].(string); strings.TrimSpace(name) == "" {
+		return CallToolResult{}, fmt.Errorf("name is required")
+	}
+	params, err := roleParams(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	reason, _ := args["reason"].(string)
+
+	role, err := s.discordClient.CreateRole(ctx, guildID, params, reason, s.actingMember(claims))
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	r := toDiscordRole(role)
+	return structuredResult(fmt.Sprintf("Role %s created. Role ID: %s", r.Name, r.ID), r), nil
+}
+
+func (s *Server) handleEditRole(ctx context.Context, claims *auth.Claims, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	role, err := s.roleArg(ctx, guildID, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	params, err := roleParams(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	if *params == (discordgo.RoleParams{}) {
+		return CallToolResult{}, fmt.Errorf("nothing to change: pass name, color, permissions, hoist or mentionable")
+	}
+	reason, _ := args["reason"].(string)
+
+	edited, err := s.discordClient.EditRole(ctx, guildID, role.ID, params, reason, s.actingMember(claims))
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to edit role: %w", err)
+	}
+
+	r := toDiscordRole(edited)
+	return structuredResult(fmt.Sprintf("Role %s (%s) updated", r.Name, r.ID), r), nil
+}
+
+func (s *Server) handleDeleteRole(ctx context.Context, claims *auth.Claims, args map[string]interface{}) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	role, err := s.roleArg(ctx, guildID, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	reason, _ := args["reason"].(string)
+
+	if err := s.discordClient.DeleteRole(ctx, guildID, role.ID, reason, s.actingMember(claims)); err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Role %s (%s) deleted", role.Name, role.ID),
+		RoleActionResult{Action: "delete_role", GuildID: guildID, RoleID: role.ID, Reason: reason},
+	), nil
+}
+
+func (s *Server) handleMemberRole(ctx context.Context, claims *auth.Claims, args map[string]interface{}, add bool) (CallToolResult, error) {
+	guildID, err := s.guildArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	userID, err := userIDArg(args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	role, err := s.roleArg(ctx, guildID, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	reason, _ := args["reason"].(string)
+
+	action, text := "add_member_role", "Gave %s the role %s"
+	if add {
+		err = s.discordClient.AddMemberRole(ctx, guildID, userID, role.ID, reason, s.actingMember(claims))
+	} else {
+		action, text = "remove_member_role", "Took from %s the role %s"
+		err = s.discordClient.RemoveMemberRole(ctx, guildID, userID, role.ID, reason, s.actingMember(claims))
+	}
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to update member roles: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf(text, userID, role.Name),
+		RoleActionResult{Action: action, GuildID: guildID, RoleID: role.ID, UserID: userID, Reason: reason},
+	), nil
+}
+
+// roleArg looks up the role argument, a role ID or name, in guildID.
+func (s *Server) roleArg(ctx context.Context, guildID string, args map[string]interface{}) (*discordgo.Role, error) {
+	ref, _ := args["role"].(string)
+	if ref == "" {
+		return nil, fmt.Errorf("role is required")
+	}
+	roles, err := s.discordClient.GetGuildRoles(ctx, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild roles: %w", err)
+	}
+	role, ok := discord.FindRole(roles, ref)
+	if !ok {
+		return nil, fmt.Errorf("guild %s has no role %q", guildID, ref)
+	}
+	return role, nil
+}
+
+// roleParams converts the role settings in args. Settings not in args are
+// left unset.
+func roleParams(args map[string]interface{}) (*discordgo.RoleParams, error) {
+	params := &discordgo.RoleParams{}
+	if name, ok := args["name"].(string); ok {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		params.Name = name
+	}
+	if raw, ok := args["color"]; ok {
+		color, err := parseColor(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color: %w", err)
+		}
+		params.Color = &color
+	}
+	if raw, ok := args["permissions"]; ok {
+		if _, ok := raw.([]interface{}); !ok {
+			return nil, fmt.Errorf("permissions must be an array of permission names")
+		}
+		permissions, err := discord.ParsePermissions(stringList(raw))
+		if err != nil {
+			return nil, err
+		}
+		params.Permissions = &permissions
+	}
+	if hoist, ok := args["hoist"].(bool); ok {
+		params.Hoist = &hoist
+	}
+	if mentionable, ok := args["mentionable"].(bool); ok {
+		params.Mentionable = &mentionable
+	}
+	return params, nil
+}
+
+func toDiscordRole(role *discordgo.Role) DiscordRole {
+	r := DiscordRole{
+		ID:          role.ID,
+		Name:        role.Name,
+		Position:    role.Position,
+		Permissions: discord.PermissionNames(role.Permissions),
+		Hoist:       role.Hoist,
+		Mentionable: role.Mentionable,
+		Managed:     role.Managed,
+	}
+	if role.Color != 0 {
+		r.Color = fmt.Sprintf("#%06x", role.Color)
+	}
+	return r
+}
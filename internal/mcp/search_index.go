@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/ReesavGupta/discord-mcp-server/internal/index"
+	"github.com/sirupsen/logrus"
+)
+
+var searchIndexTool = Tool{
+	Name:        "search_index",
+	Description: "Full-text search of the local message index, ranked by relevance",
+	InputSchema: json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {
+				"type": "string",
+				"description": "Words to search for. All words must match; use OR for alternatives, -word or NOT word to exclude, \"quotes\" for phrases and word* for prefixes"
+			},
+			"channel_id": {
+				"type": "string",
+				"description": "Only search this channel"
+			},
+			"guild_id": {
+				"type": "string",
+				"description": "Guild to search (default: the channel's guild, or the configured guild)"
+			},
+			"author_id": {
+				"type": "string",
+				"description": "Only messages by this user"
+			},
+			"limit": {
+				"type": "number",
+				"description": "Maximum number of results (default: 25, max: 100)",
+				"default": 25
+			}
+		},
+		"required": ["query"]
+	}`),
+	OutputSchema: searchIndexOutputSchema,
+}
+
+// handleSearchIndex searches one guild at a time: the one named, the
+// channel's or the configured one, the same guild checkAllowedRoles holds
+// the caller to.
+func (s *Server) handleSearchIndex(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	if s.messageIndex == nil {
+		return CallToolResult{}, fmt.Errorf("the message index is not enabled")
+	}
+
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return CallToolResult{}, fmt.Errorf("query is required")
+	}
+
+	guildIDs, err := s.targetGuilds(ctx, args)
+	if err != nil {
+		return CallToolResult{}, err
+	}
+	if len(guildIDs) > 1 {
+		return CallToolResult{}, fmt.Errorf("channel_id is not in guild %s", guildIDs[0])
+	}
+
+	opts := index.SearchOptions{GuildID: guildIDs[0], Limit: 25}
+	if channelID, ok := args["channel_id"].(string); ok && channelID != "" {
+		opts.ChannelIDs = []string{channelID}
+	}
+	opts.AuthorID, _ = args["author_id"].(string)
+	if l, ok := args["limit"].(float64); ok {
+		opts.Limit = int(l)
+		if opts.Limit > 100 {
+			opts.Limit = 100
+		}
+	}
+
+	results, err := s.messageIndex.Search(query, opts)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	result := IndexSearchResult{
+		Query:   query,
+		Count:   len(results),
+		Results: make([]IndexSearchMatch, 0, len(results)),
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d indexed messages matching %q:\n", len(results), query)
+	for _, r := range results {
+		msg := DiscordMessage{
+			ID:        r.Message.ID,
+			ChannelID: r.Message.ChannelID,
+			Author:    r.Message.Author,
+			AuthorID:  r.Message.AuthorID,
+			Content:   r.Message.Content,
+			Timestamp: r.Message.Timestamp,
+		}
+		result.Results = append(result.Results, IndexSearchMatch{Message: msg, Score: r.Score})
+
+		fmt.Fprintf(&b, "[%s] <#%s> %s (message %s, score %.2f): %s\n",
+			msg.Timestamp.Format(time.RFC3339),
+			msg.ChannelID,
+			msg.Author,
+			msg.ID,
+			r.Score,
+			msg.Content)
+	}
+
+	return structuredResult(b.String(), result), nil
+}
+
+// BackfillIndex loads recent history of the configured index channels, so
+// the index covers messages sent before the server started. It pages back
+// with Before cursors, so index.backfill may exceed discord.max_history,
+// which only bounds a single fetch. Start runs it in the background.
+func (s *Server) BackfillIndex(ctx context.Context) {
+	limit := s.config.Index.Backfill
+	if limit <= 0 {
+		return
+	}
+
+	for _, channelID := range s.config.Index.Channels {
+		logger := s.logger.WithField("channel_id", channelID)
+
+		channel, err := s.discordClient.GetChannelInfo(ctx, channelID)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to backfill message index")
+			continue
+		}
+
+		var indexed int
+		var before string
+		var oldest time.Time
+		for indexed < limit {
+			messages, cursor, err := s.discordClient.GetMessages(ctx, channelID, discord.HistoryOptions{
+				Before: before,
+				Limit:  limit - indexed,
+			})
+			if err != nil {
+				logger.WithError(err).WithField("messages", indexed).Warn("Failed to backfill message index")
+				break
+			}
+
+			// Messages fetched over REST do not carry their guild
+			for _, msg := range messages {
+				msg.GuildID = channel.GuildID
+			}
+			if err := s.messageIndex.Add(messages...); err != nil {
+				logger.WithError(err).WithField("messages", indexed).Error("Failed to backfill message index")
+				break
+			}
+			indexed += len(messages)
+			if len(messages) > 0 {
+				oldest = messages[len(messages)-1].Timestamp
+			}
+
+			if cursor == "" || len(messages) == 0 {
+				// The channel's history is exhausted
+				break
+			}
+			before = cursor
+		}
+
+		fields := logrus.Fields{"messages": indexed}
+		if !oldest.IsZero() {
+			fields["oldest"] = oldest.Format(time.RFC3339)
+		}
+		logger.WithFields(fields).Info("Backfilled message index")
+	}
+}
@@ -1,14 +1,18 @@
 package mcp
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
 	"github.com/ReesavGupta/discord-mcp-server/internal/config"
 	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/ReesavGupta/discord-mcp-server/internal/index"
+	"github.com/ReesavGupta/discord-mcp-server/internal/redact"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,11 +21,21 @@ type Server struct {
 	logger        *logrus.Logger
 	authManager   *auth.AuthManager
 	discordClient *discord.Client
-	decoder       *json.Decoder
-	encoder       *json.Encoder
+	messageIndex  *index.Index
+	prompts       []promptDefinition
+
+	sessionsMu sync.RWMutex
+	sessions   map[string]*session
 }
 
 func NewServer(cfg *config.Config, logger *logrus.Logger) (*Server, error) {
+	// Redact logs and the audit trail with the same rules
+	var redactor *redact.Redactor
+	if cfg.Logging.Redaction.Enabled {
+		redactor = newRedactor(cfg.Logging.Redaction)
+		logger.AddHook(redact.NewHook(redactor))
+	}
+
 	// Initialize auth manager
 	authManager, err := auth.NewAuthManager(
 		cfg.Auth.JWTSecret,
@@ -33,111 +47,214 @@ func NewServer(cfg *config.Config, logger *logrus.Logger) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create auth manager: %w", err)
 	}
+	if len(cfg.Auth.APIKeyPermissions) > 0 {
+		authManager.SetAPIKeyPermissions(cfg.Auth.APIKeyPermissions)
+	}
+	authManager.Auditor().SetRedactor(redactor)
+	for _, permission := range cfg.Auth.AnonymousPermissions {
+		if !strings.HasSuffix(permission, ":read") {
+			return nil, fmt.Errorf("auth.anonymous_permissions may only grant read permissions, not %q", permission)
+		}
+	}
 
 	// Initialize Discord client
 	discordClient, err := discord.NewClient(cfg.Discord.BotToken, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Discord client: %w", err)
 	}
+	if cfg.Discord.RoleCacheTTL > 0 {
+		discordClient.SetRoleCacheTTL(cfg.Discord.RoleCacheTTL)
+	}
+	if cfg.Discord.MaxHistory > 0 {
+		discordClient.SetMaxHistory(cfg.Discord.MaxHistory)
+	}
+
+	prompts, err := loadPrompts(cfg.MCP.Prompts)
+	if err != nil {
+		return nil, err
+	}
+
+	var messageIndex *index.Index
+	if cfg.Index.Enabled {
+		messageIndex, err = index.Open(cfg.Index.Path, cfg.Index.Channels, logger)
+		if err != nil {
+			return nil, err
+		}
+		discordClient.AddMessageHandler(messageIndex.HandleEvent)
+	}
 
-	return &Server{
+	s := &Server{
 		config:        cfg,
 		logger:        logger,
 		authManager:   authManager,
 		discordClient: discordClient,
-		decoder:       json.NewDecoder(os.Stdin),
-		encoder:       json.NewEncoder(os.Stdout),
-	}, nil
+		messageIndex:  messageIndex,
+		prompts:       prompts,
+		sessions:      make(map[string]*session),
+	}
+	discordClient.AddMessageHandler(s.HandleMessageEvent)
+
+	return s, nil
 }
 
 func (s *Server) Start() error {
+	var serve func() error
+	switch s.config.MCP.Transport {
+	case "stdio", "":
+		serve = func() error { return s.ServeStdio(os.Stdin, os.Stdout) }
+	case "http":
+		serve = s.serveHTTP
+	default:
+		return fmt.Errorf("unsupported transport: %s", s.config.MCP.Transport)
+	}
+
 	// Connect to Discord
 	if err := s.discordClient.Connect(); err != nil {
 		return fmt.Errorf("failed to connect to Discord: %w", err)
 	}
 
-	s.logger.Info("Discord MCP Server started")
+	s.logger.WithField("transport", s.config.MCP.Transport).Info("Discord MCP Server started")
 
-	// Main message loop
-	for {
-		var request JSONRPCRequest
-		if err := s.decoder.Decode(&request); err != nil {
-			if err == io.EOF {
-				break
-			}
-			s.logger.WithError(err).Error("Failed to decode request")
-			s.sendError(nil, ParseError, "Failed to parse JSON")
-			continue
-		}
+	defer s.authManager.Close()
+	if s.messageIndex != nil {
+		defer s.messageIndex.Close()
+		go s.BackfillIndex(context.Background())
+	}
 
-		s.logger.WithFields(logrus.Fields{
-			"method": request.Method,
-			"id":     request.ID,
-		}).Debug("Received request")
+	if err := serve(); err != nil {
+		s.discordClient.Disconnect()
+		return err
+	}
 
-		if request.JSONRPC != "2.0" {
-			s.sendError(request.ID, InvalidRequest, "Only JSON-RPC 2.0 is supported")
-			continue
-		}
+	return s.discordClient.Disconnect()
+}
 
-		if err := s.handleRequest(request); err != nil {
-			s.logger.WithError(err).Error("Failed to handle request")
-			s.sendError(request.ID, InternalError, err.Error())
-		}
+// newRedactor builds the redactor described by the logging configuration.
+func newRedactor(cfg config.RedactionConfig) *redact.Redactor {
+	return redact.New(redact.Config{
+		ContentMode:      cfg.ContentMode,
+		MaxContentLength: cfg.MaxContentLength,
+		SecretFields:     cfg.SecretFields,
+		ContentFields:    cfg.ContentFields,
+		PIIFields:        cfg.PIIFields,
+		ScrubPatterns:    cfg.ScrubPatterns,
+	})
+}
+
+// dispatch handles a single decoded request, writing any reply to w. Once
+// ctx is cancelled the reply is dropped, since the client has either
+// cancelled the request or gone away.
+func (s *Server) dispatch(ctx context.Context, sess *session, w messageWriter, request JSONRPCRequest) {
+	w = &requestWriter{ctx: ctx, messageWriter: w}
+
+	s.logger.WithFields(logrus.Fields{
+		"method":     request.Method,
+		"id":         request.ID,
+		"session_id": sess.id,
+	}).Debug("Received request")
+
+	if request.JSONRPC != "2.0" {
+		s.sendError(w, request.ID, InvalidRequest, "Only JSON-RPC 2.0 is supported")
+		return
 	}
 
-	return s.discordClient.Disconnect()
+	if err := s.handleRequest(ctx, sess, w, request); err != nil {
+		s.logger.WithError(err).Error("Failed to handle request")
+		s.sendError(w, request.ID, InternalError, err.Error())
+	}
 }
 
-func (s *Server) handleRequest(request JSONRPCRequest) error {
+func (s *Server) handleRequest(ctx context.Context, sess *session, w messageWriter, request JSONRPCRequest) error {
 	switch request.Method {
 	case "initialize":
-		return s.handleInitialize(request)
+		return s.handleInitialize(sess, w, request)
 	case "notifications/initialized", "initialized":
-		s.logger.Info("Server initialized successfully")
+		s.logger.WithField("session_id", sess.id).Info("Server initialized successfully")
 		return nil
 	case "tools/list":
-		return s.handleToolsList(request)
+		return s.handleToolsList(sess, w, request)
 	case "tools/call":
-		return s.handleToolsCall(request)
+		return s.handleToolsCall(ctx, sess, w, request)
 	case "resources/list":
-		return s.handleResourcesList(request)
+		return s.handleResourcesList(ctx, sess, w, request)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(w, request)
+	case "resources/read":
+		return s.handleResourcesRead(ctx, sess, w, request)
+	case "resources/subscribe":
+		return s.handleResourcesSubscribe(sess, w, request, true)
+	case "resources/unsubscribe":
+		return s.handleResourcesSubscribe(sess, w, request, false)
 	case "prompts/list":
-		return s.handlePromptsList(request)
-	case "cancelled":
-		return s.handleCancelled(request)
+		return s.handlePromptsList(w, request)
+	case "prompts/get":
+		return s.handlePromptsGet(ctx, sess, w, request)
+	case "notifications/cancelled", "cancelled":
+		return s.handleCancelled(sess, request)
 	default:
-		s.sendError(request.ID, MethodNotFound, "Method not implemented")
+		s.sendError(w, request.ID, MethodNotFound, "Method not implemented")
 		return nil
 	}
 }
 
-func (s *Server) handleInitialize(request JSONRPCRequest) error {
+// supportedProtocolVersions are the MCP revisions the server can speak.
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// structuredOutputVersion is the MCP revision that added output schemas
+// and structured tool results, and dropped JSON-RPC batching.
+const structuredOutputVersion = "2025-06-18"
+
+// negotiateProtocolVersion answers with the client's requested version when
+// the server supports it, and otherwise with the configured version.
+func (s *Server) negotiateProtocolVersion(request JSONRPCRequest) string {
+	if params, ok := request.Params.(map[string]interface{}); ok {
+		if requested, ok := params["protocolVersion"].(string); ok && slices.Contains(supportedProtocolVersions, requested) {
+			return requested
+		}
+	}
+	return s.config.MCP.ProtocolVersion
+}
+
+// sessionProtocolVersion returns the protocol revision a session speaks,
+// which is the configured one until the client initializes.
+func (s *Server) sessionProtocolVersion(sess *session) string {
+	if version := sess.protocolVersion(); version != "" {
+		return version
+	}
+	return s.config.MCP.ProtocolVersion
+}
+
+func (s *Server) handleInitialize(sess *session, w messageWriter, request JSONRPCRequest) error {
+	version := s.negotiateProtocolVersion(request)
+	sess.setProtocolVersion(version)
+
 	response := JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      request.ID,
 		Result: InitializeResult{
-			ProtocolVersion: s.config.MCP.ProtocolVersion,
+			ProtocolVersion: version,
 			ServerInfo: ServerInfo{
 				Name:    s.config.Server.Name,
 				Version: s.config.Server.Version,
 			},
 			Capabilities: Capabilities{
-				Tools:     map[string]interface{}{},
-				Resources: map[string]interface{}{},
-				Prompts:   map[string]interface{}{},
+				Tools: map[string]interface{}{},
+				Resources: map[string]interface{}{
+					"subscribe": true,
+				},
+				Prompts: map[string]interface{}{},
 			},
 		},
 	}
 
-	return s.sendResponse(response)
+	return s.sendResponse(w, response)
 }
 
-func (s *Server) sendResponse(response interface{}) error {
-	return s.encoder.Encode(response)
+func (s *Server) sendResponse(w messageWriter, response interface{}) error {
+	return w.writeMessage(response)
 }
 
-func (s *Server) sendError(id interface{}, code int, message string) error {
+func (s *Server) sendError(w messageWriter, id interface{}, code int, message string) error {
 	response := JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
@@ -146,5 +263,39 @@ func (s *Server) sendError(id interface{}, code int, message string) error {
 			Message: message,
 		},
 	}
-	return s.encoder.Encode(response)
+	return w.writeMessage(response)
+}
+
+func (s *Server) addSession(sess *session) {
+	s.sessionsMu.Lock()
+	s.sessions[sess.id] = sess
+	s.sessionsMu.Unlock()
+}
+
+func (s *Server) lookupSession(id string) *session {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	return s.sessions[id]
+}
+
+func (s *Server) listSessions() []*session {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+func (s *Server) removeSession(id string) {
+	s.sessionsMu.Lock()
+	sess, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.sessionsMu.Unlock()
+
+	if ok {
+		sess.close()
+	}
 }
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *Server) handleResourcesSubscribe(sess *session, w messageWriter, request JSONRPCRequest, subscribe bool) error {
+	params, ok := request.Params.(map[string]interface{})
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, "Invalid parameters")
+		return nil
+	}
+
+	uri, ok := params["uri"].(string)
+	if !ok {
+		s.sendError(w, request.ID, InvalidParams, "uri is required")
+		return nil
+	}
+
+	if subscribe {
+		ref, err := parseResourceURI(uri)
+		if err != nil {
+			s.sendError(w, request.ID, ResourceNotFound, err.Error())
+			return nil
+		}
+		if ref.kind != resourceMessages {
+			s.sendError(w, request.ID, InvalidParams, "Only channel message history resources support subscriptions")
+			return nil
+		}
+
+		claims := sess.caller()
+		if permission := resourcePermissions[ref.kind]; claims == nil || !claims.HasPermission(permission) {
+			s.sendError(w, request.ID, Forbidden, fmt.Sprintf("permission %s required", permission))
+			return nil
+		}
+
+		// Normalize so query parameters such as ?limit= match events
+		uri = messagesURI(ref.id)
+		sess.subscribe(uri)
+	} else {
+		if ref, err := parseResourceURI(uri); err == nil && ref.kind == resourceMessages {
+			uri = messagesURI(ref.id)
+		}
+		sess.unsubscribe(uri)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"session_id": sess.id,
+		"uri":        uri,
+		"subscribe":  subscribe,
+	}).Debug("Updated resource subscription")
+
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  map[string]interface{}{},
+	}
+
+	return s.sendResponse(w, response)
+}
+
+// HandleMessageEvent notifies every session subscribed to a channel's
+// history that it has changed. The server registers it with the Discord
+// client for gateway message events.
+func (s *Server) HandleMessageEvent(event discord.MessageEvent) {
+	uri := messagesURI(event.ChannelID)
+	notification := JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/resources/updated",
+		Params:  ResourceUpdatedParams{URI: uri},
+	}
+
+	for _, sess := range s.listSessions() {
+		if !sess.subscribed(uri) {
+			continue
+		}
+
+		if err := sess.notify(notification); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"session_id": sess.id,
+				"uri":        uri,
+			}).Debug("Failed to deliver resource update")
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// threadTools create, find and manage threads. Thread messages are read
+// with get_messages, passing the thread ID as the channel.
+var threadTools = []Tool{
+	{
+		Name:        "create_thread",
+		Description: "Start a thread from a message, or a standalone thread in a channel",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the channel to create the thread in"
+				},
+				"name": {
+					"type": "string",
+					"description": "The thread's name"
+				},
+				"message_id": {
+					"type": "string",
+					"description": "Start the thread from this message"
+				},
+				"private": {
+					"type": "boolean",
+					"description": "Create a private thread. Only for threads not started from a message",
+					"default": false
+				},
+				"auto_archive_minutes": {
+					"type": "number",
+					"enum": [60, 1440, 4320, 10080],
+					"description": "Archive the thread after this many minutes without activity"
+				}
+			},
+			"required": ["channel_id", "name"]
+		}`),
+		OutputSchema: threadOutputSchema,
+	},
+	{
+		Name:        "list_threads",
+		Description: "List the active or archived threads in a channel. Read a thread's messages with get_messages",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"channel_id": {
+					"type": "string",
+					"description": "The ID of the parent channel"
+				},
+				"archived": {
+					"type": "boolean",
+					"description": "List archived threads instead of active ones",
+					"default": false
+				},
+				"private": {
+					"type": "boolean",
+					"description": "With archived, list private rather than public threads",
+					"default": false
+				},
+				"before": {
+					"type": "string",
+					"description": "With archived, only threads archived before this timestamp (ISO 8601), e.g. the cursor from a previous call"
+				},
+				"limit": {
+					"type": "number",
+					"description": "With archived, maximum number of threads to return (default: 50, max: 100)",
+					"default": 50
+				}
+			},
+			"required": ["channel_id"]
+		}`),
+		OutputSchema: threadListOutputSchema,
+	},
+	{
+		Name:         "join_thread",
+		Description:  "Add the bot to a thread",
+		InputSchema:  threadRefSchema,
+		OutputSchema: threadActionOutputSchema,
+	},
+	{
+		Name:         "leave_thread",
+		Description:  "Remove the bot from a thread",
+		InputSchema:  threadRefSchema,
+		OutputSchema: threadActionOutputSchema,
+	},
+	{
+		Name:        "archive_thread",
+		Description: "Archive a thread, optionally locking it, or reopen it",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"thread_id": {
+					"type": "string",
+					"description": "The ID of the thread"
+				},
+				"archived": {
+					"type": "boolean",
+					"description": "Set to false to reopen an archived thread",
+					"default": true
+				},
+				"locked": {
+					"type": "boolean",
+					"description": "Lock or unlock the thread. Locked threads can only be reopened by moderators"
+				}
+			},
+			"required": ["thread_id"]
+		}`),
+		OutputSchema: threadOutputSchema,
+	},
+}
+
+var threadRefSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"thread_id": {
+			"type": "string",
+			"description": "The ID of the thread"
+		}
+	},
+	"required": ["thread_id"]
+}`)
+
+func (s *Server) handleCreateThread(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, ok := args["channel_id"].(string)
+	if !ok || channelID == "" {
+		return CallToolResult{}, fmt.Errorf("channel_id is required")
+	}
+	opts := discord.ThreadOptions{}
+	opts.Name, _ = args["name"].(string)
+	if strings.TrimSpace(opts.Name) == "" {
+		return CallToolResult{}, fmt.Errorf("name is required")
+	}
+	opts.MessageID, _ = args["message_id"].(string)
+	opts.Private, _ = args["private"].(bool)
+	if opts.Private && opts.MessageID != "" {
+		return CallToolResult{}, fmt.Errorf("threads started from a message cannot be private")
+	}
+	var err error
+	if opts.AutoArchiveMinutes, err = autoArchiveArg(args); err != nil {
+		return CallToolResult{}, err
+	}
+
+	thread, err := s.discordClient.CreateThread(ctx, channelID, opts)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to create thread: %w", err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("Thread %q created. Thread ID: %s", thread.Name, thread.ID),
+		toDiscordThread(thread),
+	), nil
+}
+
+func (s *Server) handleListThreads(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	channelID, ok := args["channel_id"].(string)
+	if !ok || channelID == "" {
+		return CallToolResult{}, fmt.Errorf("channel_id is required")
+	}
+	archived, _ := args["archived"].(bool)
+
+	var threads []*discordgo.Channel
+	var hasMore bool
+	var err error
+	if archived {
+		private, _ := args["private"].(bool)
+
+		var before *time.Time
+		if b, ok := args["before"].(string); ok && b != "" {
+			t, err := time.Parse(time.RFC3339, b)
+			if err != nil {
+				return CallToolResult{}, fmt.Errorf("invalid before timestamp: %w", err)
+			}
+			before = &t
+		}
+
+		limit := 50
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		threads, hasMore, err = s.discordClient.GetArchivedThreads(ctx, channelID, private, before, limit)
+	} else {
+		threads, err = s.discordClient.GetActiveThreads(ctx, channelID)
+	}
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to list threads: %w", err)
+	}
+
+	result := ThreadListResult{
+		ChannelID: channelID,
+		Archived:  archived,
+		Count:     len(threads),
+		Threads:   make([]DiscordThread, 0, len(threads)),
+	}
+	state := "active"
+	if archived {
+		state = "archived"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d %s threads in channel %s:\n", len(threads), state, channelID)
+	for _, thread := range threads {
+		t := toDiscordThread(thread)
+		result.Threads = append(result.Threads, t)
+		fmt.Fprintf(&b, "%s (%s): %d messages", t.Name, t.ID, t.MessageCount)
+		if t.Locked {
+			b.WriteString(", locked")
+		}
+		b.WriteString("\n")
+	}
+	if hasMore && len(threads) > 0 {
+		result.NextBefore = result.Threads[len(threads)-1].ArchivedAt
+		fmt.Fprintf(&b, "More threads are available; continue with before=%q.", result.NextBefore)
+	}
+
+	return structuredResult(b.String(), result), nil
+}
+
+func (s *Server) handleJoinThread(ctx context.Context, args map[string]interface{}, join bool) (CallToolResult, error) {
+	threadID, ok := args["thread_id"].(string)
+	if !ok || threadID == "" {
+		return CallToolResult{}, fmt.Errorf("thread_id is required")
+	}
+
+	action, verb, done := "join_thread", "join", "Joined"
+	var err error
+	if join {
+		err = s.discordClient.JoinThread(ctx, threadID)
+	} else {
+		action, verb, done = "leave_thread", "leave", "Left"
+		err = s.discordClient.LeaveThread(ctx, threadID)
+	}
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to %s thread: %w", verb, err)
+	}
+
+	return structuredResult(
+		fmt.Sprintf("%s thread %s", done, threadID),
+		ThreadActionResult{Action: action, ThreadID: threadID},
+	), nil
+}
+
+func (s *Server) handleArchiveThread(ctx context.Context, args map[string]interface{}) (CallToolResult, error) {
+	threadID, ok := args["thread_id"].(string)
+	if !ok || threadID == "" {
+		return CallToolResult{}, fmt.Errorf("thread_id is required")
+	}
+
+	archived := true
+	if a, ok := args["archived"].(bool); ok {
+		archived = a
+	}
+	var locked *bool
+	if l, ok := args["locked"].(bool); ok {
+		locked = &l
+	}
+
+	thread, err := s.discordClient.ArchiveThread(ctx, threadID, archived, locked)
+	if err != nil {
+		return CallToolResult{}, fmt.Errorf("failed to update thread: %w", err)
+	}
+
+	t := toDiscordThread(thread)
+	state := "reopened"
+	if t.Archived {
+		state = "archived"
+	}
+	if t.Locked {
+		state += " and locked"
+	}
+	return structuredResult(fmt.Sprintf("Thread %s %s", threadID, state), t), nil
+}
+
+// autoArchiveArg returns the auto_archive_minutes argument, or 0 if it is
+// not set.
+func autoArchiveArg(args map[string]interface{}) (int, error) {
+	m, ok := args["auto_archive_minutes"].(float64)
+	if !ok {
+		return 0, nil
+	}
+	switch int(m) {
+	case 60, 1440, 4320, 10080:
+		return int(m), nil
+	}
+	return 0, fmt.Errorf("auto_archive_minutes must be 60, 1440, 4320 or 10080")
+}
+
+func toDiscordThread(thread *discordgo.Channel) DiscordThread {
+	t := DiscordThread{
+		ID:           thread.ID,
+		Name:         thread.Name,
+		ParentID:     thread.ParentID,
+		GuildID:      thread.GuildID,
+		OwnerID:      thread.OwnerID,
+		Private:      thread.Type == discordgo.ChannelTypeGuildPrivateThread,
+		MessageCount: thread.MessageCount,
+		MemberCount:  thread.MemberCount,
+	}
+	if meta := thread.ThreadMetadata; meta != nil {
+		t.Archived = meta.Archived
+		t.Locked = meta.Locked
+		t.AutoArchiveMinutes = meta.AutoArchiveDuration
+		if meta.Archived {
+			t.ArchivedAt = meta.ArchiveTimestamp.Format(time.RFC3339)
+		}
+	}
+	return t
+}
@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/ReesavGupta/discord-mcp-server/pkg/utils"
+)
+
+// messageWriter delivers JSON-RPC messages to a client.
+type messageWriter interface {
+	writeMessage(msg interface{}) error
+}
+
+var errNoStream = errors.New("session has no open stream")
+
+// session holds the state shared by every request a single client makes.
+// Stdio has exactly one session; the HTTP transport creates one per
+// initialize call and identifies it with the Mcp-Session-Id header.
+type session struct {
+	id string
+
+	mu            sync.Mutex
+	claims        *auth.Claims
+	version       string
+	stream        messageWriter
+	subscriptions map[string]bool
+	inflight      map[string]*inflightRequest
+	lastSeen      time.Time
+	done          chan struct{}
+	closed        bool
+}
+
+func newSession(claims *auth.Claims, stream messageWriter) *session {
+	return &session{
+		id:            utils.GenerateRandomString(32),
+		claims:        claims,
+		stream:        stream,
+		subscriptions: make(map[string]bool),
+		inflight:      make(map[string]*inflightRequest),
+		lastSeen:      time.Now(),
+		done:          make(chan struct{}),
+	}
+}
+
+// notify sends a server-initiated message to the client, if it has a
+// stream open to receive one.
+func (sess *session) notify(msg interface{}) error {
+	sess.mu.Lock()
+	stream := sess.stream
+	sess.mu.Unlock()
+
+	if stream == nil {
+		return errNoStream
+	}
+	return stream.writeMessage(msg)
+}
+
+func (sess *session) setStream(stream messageWriter) {
+	sess.mu.Lock()
+	sess.stream = stream
+	sess.mu.Unlock()
+}
+
+// clearStream detaches stream, unless it has already been replaced by a
+// newer one.
+func (sess *session) clearStream(stream messageWriter) {
+	sess.mu.Lock()
+	if sess.stream == stream {
+		sess.stream = nil
+	}
+	sess.mu.Unlock()
+}
+
+// caller returns the claims of the client that owns the session.
+func (sess *session) caller() *auth.Claims {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.claims
+}
+
+// setProtocolVersion records the protocol revision agreed on in
+// initialize.
+func (sess *session) setProtocolVersion(version string) {
+	sess.mu.Lock()
+	sess.version = version
+	sess.mu.Unlock()
+}
+
+// protocolVersion returns the protocol revision agreed on in initialize,
+// or "" if the client has not initialized yet.
+func (sess *session) protocolVersion() string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.version
+}
+
+// reauthenticate refreshes the session's claims from a later request,
+// reporting false if the credentials belong to a different caller than
+// the one that opened the session.
+func (sess *session) reauthenticate(claims *auth.Claims) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.claims.UserID != claims.UserID {
+		return false
+	}
+	sess.claims = claims
+	return true
+}
+
+func (sess *session) subscribe(uri string) {
+	sess.mu.Lock()
+	sess.subscriptions[uri] = true
+	sess.mu.Unlock()
+}
+
+func (sess *session) unsubscribe(uri string) {
+	sess.mu.Lock()
+	delete(sess.subscriptions, uri)
+	sess.mu.Unlock()
+}
+
+func (sess *session) subscribed(uri string) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.subscriptions[uri]
+}
+
+// inflightRequest is a request that can still be cancelled. It is held
+// by pointer so a finished request can tell whether a later one has
+// reused its ID.
+type inflightRequest struct {
+	cancel context.CancelFunc
+}
+
+// startRequest derives the context a request runs with from parent. If the
+// request has an ID it can be cancelled by a notifications/cancelled
+// naming that ID until the returned done function is called. If a client
+// reuses the ID of a request still in flight, the newer request is the
+// one cancelled.
+func (sess *session) startRequest(parent context.Context, request JSONRPCRequest) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	if request.ID == nil {
+		return ctx, cancel
+	}
+
+	key := requestKey(request.ID)
+	entry := &inflightRequest{cancel: cancel}
+	sess.mu.Lock()
+	sess.inflight[key] = entry
+	sess.mu.Unlock()
+
+	return ctx, func() {
+		sess.mu.Lock()
+		if sess.inflight[key] == entry {
+			delete(sess.inflight, key)
+		}
+		sess.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancelRequest cancels the in-flight request with the given ID, reporting
+// whether there was one.
+func (sess *session) cancelRequest(id interface{}) bool {
+	if id == nil {
+		return false
+	}
+
+	sess.mu.Lock()
+	entry, ok := sess.inflight[requestKey(id)]
+	sess.mu.Unlock()
+
+	if ok {
+		entry.cancel()
+	}
+	return ok
+}
+
+// requestKey identifies a request ID, keeping the string "1" distinct from
+// the number 1.
+func requestKey(id interface{}) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+func (sess *session) touch() {
+	sess.mu.Lock()
+	sess.lastSeen = time.Now()
+	sess.mu.Unlock()
+}
+
+func (sess *session) idleSince() time.Time {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.lastSeen
+}
+
+func (sess *session) close() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !sess.closed {
+		sess.closed = true
+		close(sess.done)
+		for _, entry := range sess.inflight {
+			entry.cancel()
+		}
+	}
+}
+
+// encoderWriter writes newline-delimited JSON to a stream, as used by the
+// stdio transport.
+type encoderWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func newEncoderWriter(w io.Writer) *encoderWriter {
+	return &encoderWriter{encoder: json.NewEncoder(w)}
+}
+
+func (ew *encoderWriter) writeMessage(msg interface{}) error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	return ew.encoder.Encode(msg)
+}
+
+// requestWriter drops replies to a request once its context is done.
+type requestWriter struct {
+	messageWriter
+	ctx context.Context
+}
+
+func (rw *requestWriter) writeMessage(msg interface{}) error {
+	if err := rw.ctx.Err(); err != nil {
+		return err
+	}
+	return rw.messageWriter.writeMessage(msg)
+}
+
+// bufferWriter collects the replies to a single HTTP POST so they can be
+// returned in its response body.
+type bufferWriter struct {
+	mu       sync.Mutex
+	messages []interface{}
+}
+
+func (bw *bufferWriter) writeMessage(msg interface{}) error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	bw.messages = append(bw.messages, msg)
+	return nil
+}
+
+func (bw *bufferWriter) collected() []interface{} {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.messages
+}
+
+// maxStdioRequests bounds the stdio requests handled at once. Once that
+// many are in flight the server stops reading until one finishes, so a
+// client cannot make it start goroutines without limit.
+const maxStdioRequests = 16
+
+// ServeStdio reads newline-delimited JSON-RPC requests from r and writes
+// responses to w until r is exhausted. Requests are handled concurrently,
+// so a slow tool call does not hold up the ones behind it. Notifications,
+// such as cancellations, are handled as they are read and never wait for
+// a free slot.
+func (s *Server) ServeStdio(r io.Reader, w io.Writer) error {
+	out := newEncoderWriter(w)
+	// The stdio client is the process that launched the server, so it is
+	// trusted without credentials.
+	sess := newSession(auth.TrustedClaims("stdio"), out)
+	s.addSession(sess)
+	defer s.removeSession(sess.id)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxStdioRequests)
+	reader := bufio.NewReader(r)
+	for {
+		// Each message is on its own line, so a malformed one is skipped
+		// whole rather than leaving the rest of the stream unreadable.
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var request JSONRPCRequest
+			if err := json.Unmarshal(line, &request); err != nil {
+				s.logger.WithError(err).Error("Failed to decode request")
+				s.sendError(out, nil, ParseError, "Failed to parse JSON")
+			} else if request.ID == nil && strings.HasPrefix(request.Method, "notifications/") {
+				s.dispatch(context.Background(), sess, out, request)
+			} else {
+				slots <- struct{}{}
+				ctx, done := sess.startRequest(context.Background(), request)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-slots }()
+					defer done()
+					s.dispatch(ctx, sess, out, request)
+				}()
+			}
+		}
+		if err != nil {
+			// Let requests already read finish so their replies are
+			// written before the server exits.
+			wg.Wait()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -20,6 +20,12 @@ type JSONRPCResponse struct {
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
 
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type JSONRPCError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -45,17 +51,22 @@ type Capabilities struct {
 }
 
 type Tool struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	InputSchema json.RawMessage `json:"inputSchema"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	InputSchema  json.RawMessage `json:"inputSchema"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
 }
 
 type ListToolsResult struct {
 	Tools []Tool `json:"tools"`
 }
 
+// CallToolResult carries a text rendering of a tool's result in Content
+// and, for tools that declare an output schema, the same result as JSON in
+// StructuredContent.
 type CallToolResult struct {
-	Content []ToolContent `json:"content"`
+	Content           []ToolContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
 }
 
 type ToolContent struct {
@@ -63,21 +74,190 @@ type ToolContent struct {
 	Text string `json:"text"`
 }
 
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourcesResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+type ListResourceTemplatesResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type ListPromptsResult struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+type PromptMessage struct {
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+// PromptContent is either text or an embedded resource.
+type PromptContent struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
 // Discord-specific types
 type DiscordMessage struct {
 	ID        string    `json:"id"`
 	Content   string    `json:"content"`
 	Author    string    `json:"author"`
+	AuthorID  string    `json:"author_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 	ChannelID string    `json:"channel_id"`
 }
 
+// DiscordThread is a thread channel. ArchivedAt is set for archived
+// threads and is the cursor for listing older ones.
+type DiscordThread struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	ParentID           string `json:"parent_id"`
+	GuildID            string `json:"guild_id,omitempty"`
+	OwnerID            string `json:"owner_id,omitempty"`
+	Private            bool   `json:"private"`
+	Archived           bool   `json:"archived"`
+	Locked             bool   `json:"locked"`
+	AutoArchiveMinutes int    `json:"auto_archive_minutes,omitempty"`
+	ArchivedAt         string `json:"archived_at,omitempty"`
+	MessageCount       int    `json:"message_count"`
+	MemberCount        int    `json:"member_count"`
+
+	// AppliedTags are the names of a forum post's tags.
+	AppliedTags []string `json:"applied_tags,omitempty"`
+}
+
+type DiscordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot,omitempty"`
+}
+
+// DiscordBan is a user banned from a guild.
+type DiscordBan struct {
+	User   DiscordUser `json:"user"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// DiscordMember is a user's membership of a guild.
+type DiscordMember struct {
+	UserID      string       `json:"user_id"`
+	Username    string       `json:"username"`
+	GlobalName  string       `json:"global_name,omitempty"`
+	Nickname    string       `json:"nickname,omitempty"`
+	DisplayName string       `json:"display_name"`
+	Bot         bool         `json:"bot,omitempty"`
+	Roles       []DiscordRef `json:"roles"`
+	JoinedAt    string       `json:"joined_at,omitempty"`
+	Pending     bool         `json:"pending,omitempty"`
+
+	// TimedOutUntil is set while the member is timed out.
+	TimedOutUntil string `json:"timed_out_until,omitempty"`
+}
+
+// DiscordRole is a guild role. Roles higher in Position outrank lower ones.
+type DiscordRole struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Color       string   `json:"color,omitempty"`
+	Position    int      `json:"position"`
+	Permissions []string `json:"permissions"`
+	Hoist       bool     `json:"hoist,omitempty"`
+	Mentionable bool     `json:"mentionable,omitempty"`
+	Managed     bool     `json:"managed,omitempty"`
+}
+
+// DiscordRef names an object, such as a role, by ID.
+type DiscordRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type DiscordChannel struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	Type     int    `json:"type"`
+	TypeName string `json:"type_name"`
 	GuildID  string `json:"guild_id"`
 	Position int    `json:"position"`
+	ParentID string `json:"parent_id,omitempty"`
+	Topic    string `json:"topic,omitempty"`
+	NSFW     bool   `json:"nsfw,omitempty"`
+	Slowmode int    `json:"slowmode_seconds,omitempty"`
+
+	PermissionOverwrites []DiscordOverwrite `json:"permission_overwrites,omitempty"`
+
+	// AvailableTags are the tags posts in a forum channel can have.
+	AvailableTags []DiscordForumTag `json:"available_tags,omitempty"`
+}
+
+// DiscordOverwrite allows or denies permissions in a channel to a role or
+// member, overriding their guild-wide permissions.
+type DiscordOverwrite struct {
+	ID    string   `json:"id"`
+	Type  string   `json:"type"`
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+type DiscordForumTag struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Moderated bool   `json:"moderated,omitempty"`
+	Emoji     string `json:"emoji,omitempty"`
+}
+
+type DiscordGuild struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	MemberCount int              `json:"member_count"`
+	Channels    []DiscordChannel `json:"channels"`
 }
 
 // Error codes
@@ -87,4 +267,9 @@ const (
 	MethodNotFound = -32601
 	InvalidParams  = -32602
 	InternalError  = -32603
+
+	// Server-defined errors
+	Unauthorized     = -32001
+	ResourceNotFound = -32002 // fixed by the MCP specification
+	Forbidden        = -32003
 )
@@ -0,0 +1,220 @@
+// Package redact scrubs secrets, personal data and message content from log
+// fields and audit records before they leave the process.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Content modes
+const (
+	ContentFull     = "full"
+	ContentTruncate = "truncate"
+	ContentMask     = "mask"
+	ContentHash     = "hash"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	defaultSecretFields  = []string{"api_key", "apikey", "token", "bot_token", "jwt", "jwt_secret", "secret", "password", "authorization"}
+	defaultContentFields = []string{"content", "text"}
+	defaultPIIFields     = []string{"email", "phone", "ip", "ip_address", "remote_addr"}
+
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
+
+type Config struct {
+	// ContentMode controls how message content is written: full, truncate,
+	// mask or hash.
+	ContentMode      string
+	MaxContentLength int
+	// Extra field names to treat as secrets, content or PII, in addition to
+	// the built-in lists. Matching is case-insensitive.
+	SecretFields  []string
+	ContentFields []string
+	PIIFields     []string
+	// ScrubPatterns replaces email and IPv4 addresses found in any other
+	// string value.
+	ScrubPatterns bool
+}
+
+// Redactor rewrites values according to the field they are stored under.
+type Redactor struct {
+	contentMode      string
+	maxContentLength int
+	secretFields     map[string]bool
+	contentFields    map[string]bool
+	piiFields        map[string]bool
+	scrubPatterns    bool
+}
+
+func New(config Config) *Redactor {
+	mode := config.ContentMode
+	if mode == "" {
+		mode = ContentTruncate
+	}
+	maxLength := config.MaxContentLength
+	if maxLength <= 0 {
+		maxLength = 64
+	}
+
+	return &Redactor{
+		contentMode:      mode,
+		maxContentLength: maxLength,
+		secretFields:     fieldSet(defaultSecretFields, config.SecretFields),
+		contentFields:    fieldSet(defaultContentFields, config.ContentFields),
+		piiFields:        fieldSet(defaultPIIFields, config.PIIFields),
+		scrubPatterns:    config.ScrubPatterns,
+	}
+}
+
+// Fingerprint returns a short, stable hash of a secret that identifies it
+// without revealing it.
+func Fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Value returns v with secrets, PII and content redacted, based on the field
+// name key and, for nested maps, the names of their own fields. v itself is
+// never modified.
+func (r *Redactor) Value(key string, v interface{}) interface{} {
+	if r == nil || v == nil {
+		return v
+	}
+
+	name := strings.ToLower(key)
+	switch {
+	case r.secretFields[name]:
+		if s, ok := v.(string); ok && s != "" {
+			return "fp:" + Fingerprint(s)
+		}
+		return redacted
+	case r.piiFields[name]:
+		return redacted
+	}
+
+	switch value := v.(type) {
+	case string:
+		if r.contentFields[name] {
+			return r.Content(value)
+		}
+		return r.scrub(value)
+	case error:
+		return r.scrub(value.Error())
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = r.Value(k, item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = r.Value(key, item)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = r.Value(key, item)
+		}
+		return out
+	case time.Time, time.Duration:
+		return v
+	case fmt.Stringer:
+		// A Stringer's text can carry anything, so it is redacted like
+		// any other string.
+		return r.Value(key, value.String())
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Array:
+		// Walk structured values in their JSON form so nested fields are
+		// matched by the names they are written under.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return v
+		}
+		return r.Value(key, generic)
+	}
+
+	return v
+}
+
+// Content applies the configured content mode to message content.
+func (r *Redactor) Content(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	switch r.contentMode {
+	case ContentFull:
+		return s
+	case ContentMask:
+		return fmt.Sprintf("[%d chars]", utf8.RuneCountInString(s))
+	case ContentHash:
+		return "sha256:" + Fingerprint(s)
+	default:
+		runes := []rune(r.scrub(s))
+		if len(runes) <= r.maxContentLength {
+			return string(runes)
+		}
+		return fmt.Sprintf("%s... [%d chars]", string(runes[:r.maxContentLength]), len(runes))
+	}
+}
+
+func (r *Redactor) scrub(s string) string {
+	if !r.scrubPatterns {
+		return s
+	}
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	return ipv4Pattern.ReplaceAllString(s, "[ip]")
+}
+
+// Hook is a logrus hook that redacts every entry's fields before it is
+// formatted.
+type Hook struct {
+	redactor *Redactor
+}
+
+func NewHook(redactor *Redactor) *Hook {
+	return &Hook{redactor: redactor}
+}
+
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	for key, value := range entry.Data {
+		entry.Data[key] = h.redactor.Value(key, value)
+	}
+	return nil
+}
+
+func fieldSet(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, name := range list {
+			set[strings.ToLower(name)] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogHashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	logger := logrus.New()
+
+	al, err := auth.NewAuditLogger(path, logger)
+	require.NoError(t, err)
+	al.LogAuth(auth.MethodAPIKey, "api_key:abc", true)
+	al.LogToolCall("moderate_content", "123", map[string]interface{}{"action": "ban_user"}, "banned", nil, 20*time.Millisecond)
+	require.NoError(t, al.Close())
+
+	// Reopening continues the existing chain
+	al, err = auth.NewAuditLogger(path, logger)
+	require.NoError(t, err)
+	al.LogToolCall("send_message", "123", nil, nil, errors.New("boom"), time.Millisecond)
+	require.NoError(t, al.Close())
+
+	count, head, err := auth.VerifyAuditLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Len(t, head, 64)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[1], `"tool":"moderate_content"`)
+	assert.Contains(t, lines[2], `"error":"boom"`)
+
+	t.Run("DetectsEdit", func(t *testing.T) {
+		tampered := strings.Replace(string(data), "ban_user", "kick_user", 1)
+		require.NoError(t, os.WriteFile(path, []byte(tampered), 0600))
+
+		_, _, err := auth.VerifyAuditLog(path)
+		assert.ErrorContains(t, err, "record 2")
+	})
+
+	t.Run("DetectsRemoval", func(t *testing.T) {
+		removed := lines[0] + "\n" + lines[2] + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(removed), 0600))
+
+		_, _, err := auth.VerifyAuditLog(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("SkipsBlankLines", func(t *testing.T) {
+		padded := lines[0] + "\n\n" + lines[1] + "\n \n" + lines[2] + "\n\n"
+		require.NoError(t, os.WriteFile(path, []byte(padded), 0600))
+
+		count, verified, err := auth.VerifyAuditLog(path)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, head, verified)
+
+		// Appending continues from the last record, not the blank line
+		al, err := auth.NewAuditLogger(path, logger)
+		require.NoError(t, err)
+		al.LogAuth(auth.MethodAPIKey, "api_key:abc", true)
+		require.NoError(t, al.Close())
+
+		count, _, err = auth.VerifyAuditLog(path)
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+}
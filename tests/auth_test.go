@@ -0,0 +1,315 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "test-secret-that-is-long-enough-1234"
+
+func newTestAuthManager(t *testing.T) *auth.AuthManager {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	am, err := auth.NewAuthManager(testJWTSecret, []string{"key-1", ""}, logger, false, "")
+	require.NoError(t, err)
+	return am
+}
+
+func TestAuthenticate(t *testing.T) {
+	am := newTestAuthManager(t)
+
+	t.Run("JWT", func(t *testing.T) {
+		token, err := am.GenerateToken("user-1", []string{"messages:read"}, "bot-1")
+		require.NoError(t, err)
+
+		claims, err := am.Authenticate(token, "")
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims.UserID)
+		assert.Equal(t, []string{"messages:read"}, claims.Permissions)
+	})
+
+	t.Run("APIKeyHeader", func(t *testing.T) {
+		claims, err := am.Authenticate("", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, "api_key:"+auth.KeyFingerprint("key-1"), claims.UserID)
+	})
+
+	t.Run("APIKeyBearer", func(t *testing.T) {
+		_, err := am.Authenticate("key-1", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := am.Authenticate("", "")
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		other, err := auth.NewAuthManager("another-secret-entirely-5678", nil, logrus.New(), false, "")
+		require.NoError(t, err)
+		token, err := other.GenerateToken("user-1", nil, "bot-1")
+		require.NoError(t, err)
+
+		_, err = am.Authenticate(token, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTPTransportRequiresAuth(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Auth.Required = true
+	cfg.Auth.JWTSecret = testJWTSecret
+	cfg.Auth.APIKeys = []string{"key-1"}
+	ts := newTestHTTPServer(t, cfg)
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		resp, err := http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"initialize"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		require.NotNil(t, reply.Error)
+		assert.Equal(t, mcp.Unauthorized, reply.Error.Code)
+		assert.Equal(t, float64(7), reply.ID)
+	})
+
+	t.Run("SessionBoundToCaller", func(t *testing.T) {
+		sessionID := initializeWithHeader(t, ts.URL, "X-API-Key", "key-1")
+
+		token, err := newTestAuthManager(t).GenerateToken("user-2", nil, "bot-1")
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp",
+			strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+		require.NoError(t, err)
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func initializeWithHeader(t *testing.T, url, header, value string) string {
+	req, err := http.NewRequest(http.MethodPost, url+"/mcp",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	require.NoError(t, err)
+	req.Header.Set(header, value)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	require.NotEmpty(t, sessionID)
+	return sessionID
+}
+
+func TestClaimsHasPermission(t *testing.T) {
+	claims := &auth.Claims{Permissions: []string{"messages:read", "moderation:*"}}
+
+	assert.True(t, claims.HasPermission("messages:read"))
+	assert.False(t, claims.HasPermission("messages:write"))
+	assert.True(t, claims.HasPermission("moderation:ban"))
+	assert.False(t, claims.HasPermission("moderationx:ban"))
+
+	assert.True(t, auth.TrustedClaims("stdio").HasPermission("moderation:ban"))
+	assert.False(t, (&auth.Claims{}).HasPermission("messages:read"))
+}
+
+func TestToolPermissions(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Auth.Required = true
+	cfg.Auth.JWTSecret = testJWTSecret
+	ts := newTestHTTPServer(t, cfg)
+
+	token, err := newTestAuthManager(t).GenerateToken("analytics", []string{"messages:read"}, "bot-1")
+	require.NoError(t, err)
+	sessionID := initializeWithHeader(t, ts.URL, "Authorization", "Bearer "+token)
+
+	call := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("ListFiltered", func(t *testing.T) {
+		resp := call(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+		var reply struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+
+		var names []string
+		for _, tool := range reply.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		assert.ElementsMatch(t, []string{"get_messages", "search_messages", "list_reactions"}, names)
+	})
+
+	t.Run("CallDenied", func(t *testing.T) {
+		resp := call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"moderate_content","arguments":{"action":"ban_user","guild_id":"1","user_id":"2"}}}`)
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		require.NotNil(t, reply.Error)
+		// Clients match on the code, so it must not change. -32002 is
+		// the specification's resource not found
+		assert.Equal(t, -32003, reply.Error.Code)
+	})
+}
+
+func TestAllowedRolesRequireDiscordIdentity(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Auth.Required = true
+	cfg.Auth.JWTSecret = testJWTSecret
+	cfg.Discord.AllowedRoles = []string{"Moderator"}
+	ts := newTestHTTPServer(t, cfg)
+
+	token, err := newTestAuthManager(t).GenerateToken("not-a-snowflake", []string{"moderation:*", "channels:manage"}, "bot-1")
+	require.NoError(t, err)
+	sessionID := initializeWithHeader(t, ts.URL, "Authorization", "Bearer "+token)
+
+	for _, params := range []string{
+		`{"name":"moderate_content","arguments":{"action":"kick_user","guild_id":"123456789012345678","user_id":"223456789012345678"}}`,
+		`{"name":"manage_forum_tags","arguments":{"channel_id":"323456789012345678","add":[{"name":"bug"}]}}`,
+	} {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+params+`}`))
+		require.NoError(t, err)
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		require.NotNil(t, reply.Error, params)
+		assert.Equal(t, mcp.Forbidden, reply.Error.Code, params)
+		assert.Contains(t, reply.Error.Message, "not mapped to a Discord member", params)
+	}
+}
+
+func TestAllowedRolesCoverEveryBulkDeleteChannel(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addRole("9", "500", "Moderator")
+	fd.addMember("9", alexID, "alex", "", "500")
+	fd.addMember("8", alexID, "alex", "")
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("8", "2", discordgo.ChannelTypeGuildText, false)
+	fd.addMessages("1", 1, 3, func(i int) string { return "spam" })
+	fd.addMessages("2", 11, 3, func(i int) string { return "spam" })
+
+	cfg := newTestConfig()
+	cfg.Auth.Required = true
+	cfg.Auth.JWTSecret = testJWTSecret
+	cfg.Discord.AllowedRoles = []string{"Moderator"}
+	ts := newTestHTTPServer(t, cfg)
+
+	token, err := newTestAuthManager(t).GenerateToken(alexID, []string{"moderation:*"}, "bot-1")
+	require.NoError(t, err)
+	sessionID := initializeWithHeader(t, ts.URL, "Authorization", "Bearer "+token)
+
+	call := func(args string) mcp.JSONRPCResponse {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"moderate_content","arguments":`+args+`}}`))
+		require.NoError(t, err)
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return reply
+	}
+
+	// Channel 2 is in guild 8, where alex is not a moderator
+	reply := call(`{"action":"bulk_delete","channel_id":"1","channel_ids":["2"]}`)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, mcp.Forbidden, reply.Error.Code)
+	assert.Contains(t, reply.Error.Message, "guild 8")
+	assert.Equal(t, []string{"11", "12", "13"}, fd.messageIDs("2"))
+	assert.Equal(t, []string{"1", "2", "3"}, fd.messageIDs("1"))
+
+	reply = call(`{"action":"bulk_delete","channel_ids":["1"]}`)
+	require.Nil(t, reply.Error)
+	assert.Empty(t, fd.messageIDs("1"))
+}
+
+func TestAnonymousHTTPCallers(t *testing.T) {
+	anonymous := func(t *testing.T, url, sessionID, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(body))
+		require.NoError(t, err)
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp
+	}
+	listTools := func(t *testing.T, url string) (string, []string) {
+		sessionID := anonymous(t, url, "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`).Header.Get("Mcp-Session-Id")
+		var reply struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		resp := anonymous(t, url, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		var names []string
+		for _, tool := range reply.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		return sessionID, names
+	}
+
+	t.Run("NoPermissionsByDefault", func(t *testing.T) {
+		ts := newTestHTTPServer(t, newTestConfig())
+		sessionID, names := listTools(t, ts.URL)
+		assert.Empty(t, names)
+
+		var reply mcp.JSONRPCResponse
+		resp := anonymous(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"moderate_content","arguments":{"action":"ban_user","guild_id":"1","user_id":"2"}}}`)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		require.NotNil(t, reply.Error)
+		assert.Equal(t, mcp.Forbidden, reply.Error.Code)
+	})
+
+	t.Run("ReadPermissions", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.Auth.AnonymousPermissions = []string{"messages:read"}
+		ts := newTestHTTPServer(t, cfg)
+		_, names := listTools(t, ts.URL)
+		assert.ElementsMatch(t, []string{"get_messages", "search_messages", "list_reactions"}, names)
+	})
+
+	t.Run("WritePermissionsRefused", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.Auth.AnonymousPermissions = []string{"moderation:*"}
+		logger := logrus.New()
+		logger.SetLevel(logrus.PanicLevel)
+		_, err := mcp.NewServer(cfg, logger)
+		assert.ErrorContains(t, err, "anonymous_permissions")
+	})
+}
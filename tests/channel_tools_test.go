@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type channelResult struct {
+	StructuredContent mcp.DiscordChannel `json:"structuredContent"`
+}
+
+func TestListChannels(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("9", "2", discordgo.ChannelTypeGuildCategory, false)
+	fd.addChannel("9", "3", discordgo.ChannelTypeGuildVoice, false)
+	fd.addChannel("9", "4", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("9", "5", discordgo.ChannelTypeGuildCategory, false)
+
+	// Category 5 comes before category 2, and channel 4 before 3 within 2
+	fd.findChannel("2").Position = 1
+	fd.findChannel("3").ParentID, fd.findChannel("3").Position = "2", 1
+	fd.findChannel("4").ParentID = "2"
+	fd.findChannel("4").PermissionOverwrites = []*discordgo.PermissionOverwrite{{
+		ID:    "9",
+		Type:  discordgo.PermissionOverwriteTypeRole,
+		Allow: discordgo.PermissionViewChannel,
+		Deny:  discordgo.PermissionSendMessages | discordgo.PermissionAddReactions,
+	}}
+
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		StructuredContent mcp.ChannelListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_channels", map[string]interface{}{}), &result)
+	require.Equal(t, 5, result.StructuredContent.Count)
+
+	var ids []string
+	for _, c := range result.StructuredContent.Channels {
+		ids = append(ids, c.ID)
+	}
+	assert.Equal(t, []string{"1", "5", "2", "4", "3"}, ids)
+	assert.Equal(t, "category", result.StructuredContent.Channels[1].TypeName)
+	assert.Equal(t, []mcp.DiscordOverwrite{{
+		ID:    "9",
+		Type:  "role",
+		Allow: []string{"view_channel"},
+		Deny:  []string{"add_reactions", "send_messages"},
+	}}, result.StructuredContent.Channels[3].PermissionOverwrites)
+
+	var voice struct {
+		StructuredContent mcp.ChannelListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_channels", map[string]interface{}{"type": "voice"}), &voice)
+	require.Len(t, voice.StructuredContent.Channels, 1)
+	assert.Equal(t, "3", voice.StructuredContent.Channels[0].ID)
+}
+
+func TestCreateEditAndDeleteChannel(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "2", discordgo.ChannelTypeGuildCategory, false)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var created channelResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "create_channel", map[string]interface{}{
+		"guild_id":         "9",
+		"name":             "incident-42",
+		"topic":            "Database outage",
+		"parent_id":        "2",
+		"slowmode_seconds": 10,
+		"reason":           "Incident #42",
+	}), &created)
+	channel := created.StructuredContent
+	assert.Equal(t, "text", channel.TypeName)
+	assert.Equal(t, "Database outage", channel.Topic)
+	assert.Equal(t, "2", channel.ParentID)
+	assert.Equal(t, 10, channel.Slowmode)
+
+	var edited channelResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "edit_channel", map[string]interface{}{
+		"channel_id": channel.ID,
+		"topic":      "",
+		"parent_id":  "",
+		"nsfw":       true,
+	}), &edited)
+	assert.Equal(t, channel.Name, edited.StructuredContent.Name)
+	assert.Empty(t, edited.StructuredContent.Topic)
+	assert.Empty(t, edited.StructuredContent.ParentID)
+	assert.True(t, edited.StructuredContent.NSFW)
+	assert.Equal(t, 10, edited.StructuredContent.Slowmode)
+
+	var deleted struct {
+		StructuredContent mcp.ChannelActionResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "delete_channel", map[string]interface{}{
+		"channel_id": channel.ID,
+		"reason":     "Incident resolved",
+	}), &deleted)
+	assert.Equal(t, []string{channel.ID}, deleted.StructuredContent.ChannelIDs)
+	assert.Nil(t, fd.channel(channel.ID))
+	assert.Equal(t, []string{"Incident #42", "Incident resolved"}, fd.auditLog())
+}
+
+func TestChannelToolsRejectInvalidArguments(t *testing.T) {
+	newFakeDiscord(t)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	for name, tc := range map[string]struct {
+		tool string
+		args map[string]interface{}
+		want string
+	}{
+		"no guild":     {"create_channel", map[string]interface{}{"name": "x"}, "guild_id is required"},
+		"thread type":  {"create_channel", map[string]interface{}{"guild_id": "9", "name": "x", "type": "public_thread"}, "cannot create"},
+		"slowmode":     {"create_channel", map[string]interface{}{"guild_id": "9", "name": "x", "slowmode_seconds": 30000}, "slowmode_seconds"},
+		"no changes":   {"edit_channel", map[string]interface{}{"channel_id": "1"}, "nothing to change"},
+		"no positions": {"reorder_channels", map[string]interface{}{"guild_id": "9"}, "channels is required"},
+		"bad position": {"reorder_channels", map[string]interface{}{"guild_id": "9", "channels": []interface{}{map[string]interface{}{"id": "1"}}}, "position is required"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reply := callToolRPC(t, ts.URL, sessionID, tc.tool, tc.args)
+			require.NotNil(t, reply.Error)
+			assert.Contains(t, reply.Error.Message, tc.want)
+		})
+	}
+}
+
+func TestReorderChannels(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("9", "2", discordgo.ChannelTypeGuildCategory, false)
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		StructuredContent mcp.ChannelActionResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "reorder_channels", map[string]interface{}{
+		"channels": []interface{}{
+			map[string]interface{}{"id": "1", "position": 3, "parent_id": "2"},
+		},
+	}), &result)
+	assert.Equal(t, "9", result.StructuredContent.GuildID)
+	moved := fd.channel("1")
+	assert.Equal(t, 3, moved.Position)
+	assert.Equal(t, "2", moved.ParentID)
+}
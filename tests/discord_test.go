@@ -1,11 +1,15 @@
 package tests
 
 import (
+	"context"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMessageFilter(t *testing.T) {
@@ -39,3 +43,194 @@ func TestMessageFilterWithTime(t *testing.T) {
 	assert.Equal(t, after, *filter.After)
 	assert.Equal(t, 100, filter.Limit)
 }
+
+func TestGetMessagesPaginates(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 1, 300, func(i int) string { return "message" })
+	client := newTestDiscordClient(t)
+	ctx := context.Background()
+
+	messages, cursor, err := client.GetMessages(ctx, "1", discord.HistoryOptions{Limit: 250})
+	require.NoError(t, err)
+	require.Len(t, messages, 250)
+	assert.Equal(t, "300", messages[0].ID)
+	assert.Equal(t, "51", messages[249].ID)
+	assert.Equal(t, "51", cursor)
+	assert.Equal(t, 3, fd.requestCount())
+
+	messages, cursor, err = client.GetMessages(ctx, "1", discord.HistoryOptions{Before: cursor, Limit: 250})
+	require.NoError(t, err)
+	assert.Len(t, messages, 50)
+	assert.Equal(t, "1", messages[49].ID)
+	assert.Empty(t, cursor)
+}
+
+func TestGetMessagesAfterCursor(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 1, 300, func(i int) string { return "message" })
+	client := newTestDiscordClient(t)
+
+	messages, cursor, err := client.GetMessages(context.Background(), "1", discord.HistoryOptions{After: "100", Limit: 150})
+	require.NoError(t, err)
+	require.Len(t, messages, 150)
+	assert.Equal(t, "250", messages[0].ID)
+	assert.Equal(t, "101", messages[149].ID)
+	assert.Equal(t, "250", cursor)
+}
+
+func TestGetMessagesLimits(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 1, 300, func(i int) string { return "message" })
+	client := newTestDiscordClient(t)
+	client.SetMaxHistory(120)
+
+	messages, _, err := client.GetMessages(context.Background(), "1", discord.HistoryOptions{Limit: 500})
+	require.NoError(t, err)
+	assert.Len(t, messages, 120)
+
+	messages, _, err = client.GetMessages(context.Background(), "1", discord.HistoryOptions{Around: "150", Limit: 500})
+	require.NoError(t, err)
+	assert.Len(t, messages, 100)
+
+	_, _, err = client.GetMessages(context.Background(), "1", discord.HistoryOptions{Before: "10", After: "5"})
+	assert.Error(t, err)
+}
+
+func TestSearchMessagesWalksHistory(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 1, 300, func(i int) string {
+		if i <= 50 && i%10 == 0 {
+			return "needle"
+		}
+		return "hay"
+	})
+	client := newTestDiscordClient(t)
+
+	messages, err := client.SearchMessages(context.Background(), discord.MessageFilter{
+		ChannelID: "1",
+		Content:   "needle",
+		Limit:     3,
+	})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	assert.Equal(t, []string{"50", "40", "30"}, ids)
+	assert.Equal(t, 3, fd.requestCount())
+}
+
+func TestSearchMessagesStopsAtAfter(t *testing.T) {
+	fd := newFakeDiscord(t)
+	added := fd.addMessages("1", 1, 300, func(i int) string { return "hay" })
+	client := newTestDiscordClient(t)
+
+	after := added[249].Timestamp
+	messages, err := client.SearchMessages(context.Background(), discord.MessageFilter{
+		ChannelID: "1",
+		Content:   "needle",
+		After:     &after,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	assert.Equal(t, 1, fd.requestCount())
+}
+
+func TestSearchMessagesAcrossGuild(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("9", "2", discordgo.ChannelTypeGuildText, true)
+	fd.addChannel("9", "3", discordgo.ChannelTypeGuildCategory, false)
+	fd.addChannel("9", "4", discordgo.ChannelTypeGuildText, false)
+	fd.addMessages("1", 1, 10, func(i int) string { return "release notes" })
+	fd.addMessages("4", 11, 10, func(i int) string { return "release party" })
+	client := newTestDiscordClient(t)
+
+	messages, err := client.SearchMessages(context.Background(), discord.MessageFilter{
+		GuildID: "9",
+		Content: "release",
+		Limit:   15,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 15)
+	assert.Equal(t, "20", messages[0].ID)
+	assert.Equal(t, "4", messages[0].ChannelID)
+	assert.Equal(t, "1", messages[14].ChannelID)
+
+	_, err = client.SearchMessages(context.Background(), discord.MessageFilter{ChannelIDs: []string{"1", "2"}})
+	assert.Error(t, err)
+}
+
+func TestSearchMessagesAcrossGuildThreads(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addForum("9", "2")
+	fd.addThread("2", "3", false, time.Time{})
+	fd.addThread("1", "4", false, time.Now().Add(-time.Hour))
+	fd.addMessages("1", 1, 2, func(i int) string { return "release notes" })
+	fd.addMessages("3", 11, 2, func(i int) string { return "release post" })
+	fd.addMessages("4", 21, 2, func(i int) string { return "release archive" })
+	client := newTestDiscordClient(t)
+
+	messages, err := client.SearchMessages(context.Background(), discord.MessageFilter{
+		GuildID: "9",
+		Content: "release",
+	})
+	require.NoError(t, err)
+	var channelIDs []string
+	for _, message := range messages {
+		channelIDs = append(channelIDs, message.ChannelID)
+	}
+	assert.ElementsMatch(t, []string{"1", "1", "3", "3"}, channelIDs)
+}
+
+func TestSearchMessagesFilters(t *testing.T) {
+	fd := newFakeDiscord(t)
+	human := &discordgo.User{ID: "42", Username: "human"}
+	bot := &discordgo.User{ID: "43", Username: "bot", Bot: true}
+	for _, msg := range []*discordgo.Message{
+		{ID: "1", Content: "error code E1234", Author: human},
+		{ID: "2", Content: "see https://example.com", Author: human},
+		{ID: "3", Content: "screenshot", Author: human, Attachments: []*discordgo.MessageAttachment{{ID: "a"}}},
+		{ID: "4", Content: "build passed", Author: bot, Embeds: []*discordgo.MessageEmbed{{Title: "CI"}}},
+		{ID: "5", Content: "hey <@7>", Author: human, Mentions: []*discordgo.User{{ID: "7"}}},
+		{ID: "6", Content: "ping mods", Author: human, MentionRoles: []string{"99"}, Pinned: true},
+	} {
+		msg.ChannelID = "1"
+		fd.addMessage(msg)
+	}
+	client := newTestDiscordClient(t)
+
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		filter discord.MessageFilter
+		want   []string
+	}{
+		{"pattern", discord.MessageFilter{Pattern: regexp.MustCompile(`E\d{4}`)}, []string{"1"}},
+		{"has attachment", discord.MessageFilter{HasAttachment: &yes}, []string{"3"}},
+		{"has link", discord.MessageFilter{HasLink: &yes}, []string{"2"}},
+		{"has embed", discord.MessageFilter{HasEmbed: &yes}, []string{"4"}},
+		{"no embed", discord.MessageFilter{HasEmbed: &no}, []string{"6", "5", "3", "2", "1"}},
+		{"pinned", discord.MessageFilter{Pinned: &yes}, []string{"6"}},
+		{"mentions user", discord.MessageFilter{MentionsUser: "7"}, []string{"5"}},
+		{"mentions role", discord.MessageFilter{MentionsRole: "99"}, []string{"6"}},
+		{"bots", discord.MessageFilter{AuthorType: discord.AuthorBot}, []string{"4"}},
+		{"humans with links", discord.MessageFilter{AuthorType: discord.AuthorHuman, HasLink: &yes}, []string{"2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.ChannelID = "1"
+			messages, err := client.SearchMessages(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, msg := range messages {
+				ids = append(ids, msg.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
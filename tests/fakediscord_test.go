@@ -0,0 +1,1193 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/config"
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDiscord serves the parts of the Discord REST API the client uses,
+// backed by in-memory channels.
+type fakeDiscord struct {
+	mu        sync.Mutex
+	messages  map[string][]*discordgo.Message
+	channels  map[string][]*discordgo.Channel
+	forbidden map[string]bool
+	requests  int
+
+	// sent records messages posted to channels, with attached files by
+	// name.
+	sent []sentMessage
+
+	// pinned and reactions hold message state by message ID; reactions
+	// maps each emoji to the IDs of the users who reacted with it.
+	pinned    map[string]bool
+	reactions map[string]map[string][]string
+
+	// threadMembers maps thread IDs to the IDs of their members.
+	threadMembers map[string][]string
+	nextID        int
+
+	// members and roles hold each guild's members, in user ID order, and
+	// roles.
+	members map[string][]*discordgo.Member
+	roles   map[string][]*discordgo.Role
+
+	// bans holds each guild's bans in user ID order.
+	bans map[string][]*discordgo.GuildBan
+
+	// owners maps guild IDs to their owner's user ID. Only guilds with an
+	// owner can be fetched.
+	owners map[string]string
+
+	// auditReasons records the audit log reason of each request that gave
+	// one.
+	auditReasons []string
+
+	// bulkDeletes counts bulk delete requests.
+	bulkDeletes int
+
+	// Requests for stallPaths are held until the client gives up on them;
+	// see stall.
+	stallPaths       map[string]bool
+	stalled, aborted chan struct{}
+}
+
+type sentMessage struct {
+	ChannelID string
+	Payload   discordgo.MessageSend
+	Files     map[string][]byte
+}
+
+// newFakeDiscord starts a fake Discord API and points discordgo at it for
+// the duration of the test.
+func newFakeDiscord(t *testing.T) *fakeDiscord {
+	fd := &fakeDiscord{
+		messages:  make(map[string][]*discordgo.Message),
+		channels:  make(map[string][]*discordgo.Channel),
+		forbidden: make(map[string]bool),
+		pinned:    make(map[string]bool),
+		reactions: make(map[string]map[string][]string),
+
+		threadMembers: make(map[string][]string),
+		members:       make(map[string][]*discordgo.Member),
+		roles:         make(map[string][]*discordgo.Role),
+		bans:          make(map[string][]*discordgo.GuildBan),
+		owners:        make(map[string]string),
+		nextID:        5000,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(fd.serveHTTP))
+	t.Cleanup(ts.Close)
+
+	previousChannels, previousGuilds, previousUsers := discordgo.EndpointChannels, discordgo.EndpointGuilds, discordgo.EndpointUsers
+	discordgo.EndpointChannels = ts.URL + "/channels/"
+	discordgo.EndpointGuilds = ts.URL + "/guilds/"
+	discordgo.EndpointUsers = ts.URL + "/users/"
+	t.Cleanup(func() {
+		discordgo.EndpointChannels, discordgo.EndpointGuilds, discordgo.EndpointUsers = previousChannels, previousGuilds, previousUsers
+	})
+
+	return fd
+}
+
+// Members of the guild newTestGuild sets up, besides the bot.
+const (
+	alexID = "100000000000000001"
+	bossID = "100000000000000002"
+)
+
+// testGuild parameterises the guild newTestGuild sets up.
+type testGuild struct {
+	// botPermissions are given to Bot, the bot's highest role.
+	botPermissions int64
+	// alexRoles are the IDs of the roles alex holds.
+	alexRoles []string
+	// configure, if set, adjusts the server's config before it starts.
+	configure func(cfg *config.Config)
+}
+
+// newTestGuild sets up guild 9, owned by boss, with text channel 1 and, by
+// position, the roles Admin (held by boss), Bot, Lead, Moderator (which may
+// manage roles), Oncall and the integration-managed Integration. It starts
+// a server for the guild and returns its URL and an initialized session.
+func newTestGuild(t *testing.T, guild testGuild) (*fakeDiscord, string, string) {
+	fd := newFakeDiscord(t)
+	everyone := fd.addRole("9", "9", "@everyone")
+	everyone.Permissions = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+	fd.addRole("9", "700", "Admin").Position = 10
+	bot := fd.addRole("9", "600", "Bot")
+	bot.Position, bot.Permissions = 5, guild.botPermissions
+	fd.addRole("9", "550", "Lead").Position = 4
+	moderator := fd.addRole("9", "510", "Moderator")
+	moderator.Position, moderator.Permissions = 3, discordgo.PermissionManageRoles
+	fd.addRole("9", "500", "Oncall").Position = 2
+	managed := fd.addRole("9", "501", "Integration")
+	managed.Position, managed.Managed = 1, true
+
+	fd.addMember("9", fakeBotID, "bot", "", "600")
+	fd.addMember("9", alexID, "alex", "", guild.alexRoles...)
+	fd.addMember("9", bossID, "boss", "", "700")
+	fd.setOwner("9", bossID)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	if guild.configure != nil {
+		guild.configure(cfg)
+	}
+	ts := newTestHTTPServer(t, cfg)
+	return fd, ts.URL, initializeSession(t, ts.URL)
+}
+
+// stall makes requests for paths hang until the client cancels them. The
+// returned channels receive once as each request arrives and once as it
+// is cancelled.
+func (fd *fakeDiscord) stall(paths ...string) (started <-chan struct{}, aborted <-chan struct{}) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.stallPaths = make(map[string]bool)
+	for _, path := range paths {
+		fd.stallPaths[path] = true
+	}
+	fd.stalled = make(chan struct{}, 4)
+	fd.aborted = make(chan struct{}, 4)
+	return fd.stalled, fd.aborted
+}
+
+// addMessages adds count messages to channelID with IDs first, first+1, ...
+// one minute apart, returning them oldest first.
+func (fd *fakeDiscord) addMessages(channelID string, first, count int, content func(i int) string) []*discordgo.Message {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var added []*discordgo.Message
+	for i := 0; i < count; i++ {
+		id := first + i
+		msg := &discordgo.Message{
+			ID:        strconv.Itoa(id),
+			ChannelID: channelID,
+			Content:   content(id),
+			Timestamp: base.Add(time.Duration(id) * time.Minute),
+			Author:    &discordgo.User{ID: "42", Username: "tester"},
+		}
+		fd.messages[channelID] = append(fd.messages[channelID], msg)
+		added = append(added, msg)
+	}
+	return added
+}
+
+// addMessage adds msg to its channel as is.
+func (fd *fakeDiscord) addMessage(msg *discordgo.Message) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.messages[msg.ChannelID] = append(fd.messages[msg.ChannelID], msg)
+}
+
+// addChannel adds a channel to guildID. Reading a forbidden channel's
+// messages fails with 403.
+func (fd *fakeDiscord) addChannel(guildID, channelID string, channelType discordgo.ChannelType, forbidden bool) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	fd.channels[guildID] = append(fd.channels[guildID], &discordgo.Channel{
+		ID:      channelID,
+		GuildID: guildID,
+		Name:    "channel-" + channelID,
+		Type:    channelType,
+	})
+	fd.forbidden[channelID] = forbidden
+}
+
+// sentMessages returns the messages posted so far.
+func (fd *fakeDiscord) sentMessages() []sentMessage {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return append([]sentMessage(nil), fd.sent...)
+}
+
+// auditLog returns the audit log reasons given so far.
+func (fd *fakeDiscord) auditLog() []string {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return append([]string(nil), fd.auditReasons...)
+}
+
+func (fd *fakeDiscord) requestCount() int {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.requests
+}
+
+func (fd *fakeDiscord) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	fd.mu.Lock()
+	stall := fd.stallPaths[r.URL.Path]
+	fd.mu.Unlock()
+	if stall {
+		fd.stalled <- struct{}{}
+		<-r.Context().Done()
+		fd.aborted <- struct{}{}
+		return
+	}
+
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.requests++
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if reason := r.Header.Get("X-Audit-Log-Reason"); reason != "" {
+		reason, _ = url.PathUnescape(reason)
+		fd.auditReasons = append(fd.auditReasons, reason)
+	}
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "guilds":
+		fd.getGuild(w, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "channels":
+		fd.guildChannels(w, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "members":
+		fd.listMembers(w, r, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "members" && parts[3] == "search":
+		fd.searchMembers(w, r, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "members":
+		fd.getMember(w, parts[1], parts[3])
+	case r.Method == http.MethodPatch && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "members":
+		fd.editMember(w, r, parts[1], parts[3])
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "bans":
+		fd.listBans(w, r, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "bans":
+		fd.getBan(w, parts[1], parts[3])
+	case r.Method == http.MethodDelete && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "bans":
+		fd.deleteBan(w, parts[1], parts[3])
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "users" && parts[1] == "@me":
+		json.NewEncoder(w).Encode(discordgo.User{ID: fakeBotID, Username: "bot", Bot: true})
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "roles":
+		json.NewEncoder(w).Encode(fd.roles[parts[1]])
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "roles":
+		fd.editRole(w, r, parts[1], "")
+	case r.Method == http.MethodPatch && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "roles":
+		fd.editRole(w, r, parts[1], parts[3])
+	case r.Method == http.MethodDelete && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "roles":
+		fd.deleteRole(w, parts[1], parts[3])
+	case len(parts) == 6 && parts[0] == "guilds" && parts[2] == "members" && parts[4] == "roles":
+		fd.setMemberRole(w, parts[1], parts[3], parts[5], r.Method == http.MethodPut)
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "channels":
+		fd.createChannel(w, r, parts[1])
+	case r.Method == http.MethodPatch && len(parts) == 3 && parts[0] == "guilds" && parts[2] == "channels":
+		fd.reorderChannels(w, r)
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "channels" && parts[2] == "messages":
+		if fd.forbidden[parts[1]] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Missing Access", "code": 50001}`))
+			return
+		}
+		json.NewEncoder(w).Encode(fd.page(parts[1], r.URL.Query()))
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "channels" && parts[2] == "messages":
+		fd.createMessage(w, r, parts[1])
+	case r.Method == http.MethodPost && len(parts) == 4 && parts[0] == "channels" && parts[2] == "messages" && parts[3] == "bulk-delete":
+		fd.bulkDelete(w, r, parts[1])
+	case len(parts) >= 4 && parts[0] == "channels" && parts[2] == "messages":
+		fd.serveMessage(w, r, parts[1], parts[3], parts[4:])
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "channels":
+		fd.writeChannel(w, fd.findChannel(parts[1]))
+	case r.Method == http.MethodPatch && len(parts) == 2 && parts[0] == "channels":
+		fd.editChannel(w, r, parts[1])
+	case r.Method == http.MethodDelete && len(parts) == 2 && parts[0] == "channels":
+		fd.deleteChannel(w, parts[1])
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "channels" && parts[2] == "threads":
+		fd.createThread(w, r, parts[1], "")
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "threads" && parts[3] == "active":
+		fd.activeThreads(w, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 5 && parts[0] == "channels" && parts[2] == "threads" && parts[3] == "archived":
+		fd.archivedThreads(w, r, parts[1], parts[4] == "private")
+	case len(parts) == 4 && parts[0] == "channels" && parts[2] == "thread-members" && parts[3] == "@me":
+		members := fd.threadMembers[parts[1]]
+		if r.Method == http.MethodPut {
+			fd.threadMembers[parts[1]] = append(members, fakeBotID)
+		} else {
+			var kept []string
+			for _, id := range members {
+				if id != fakeBotID {
+					kept = append(kept, id)
+				}
+			}
+			fd.threadMembers[parts[1]] = kept
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 4 && parts[0] == "channels" && parts[2] == "pins":
+		fd.pinned[parts[3]] = r.Method == http.MethodPut
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// fakeBotID is the user ID the fake API treats as the bot, i.e. "@me".
+const fakeBotID = "1"
+
+// serveMessage serves /channels/{id}/messages/{id} and the reactions below
+// it.
+func (fd *fakeDiscord) serveMessage(w http.ResponseWriter, r *http.Request, channelID, messageID string, rest []string) {
+	var msg *discordgo.Message
+	for _, m := range fd.messages[channelID] {
+		if m.ID == messageID {
+			msg = m
+		}
+	}
+	if msg == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Unknown Message", "code": 10008}`))
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(rest) == 0:
+		reply := *msg
+		reply.Pinned = fd.pinned[msg.ID]
+		for emoji, users := range fd.reactions[msg.ID] {
+			reply.Reactions = append(reply.Reactions, &discordgo.MessageReactions{
+				Count: len(users),
+				Me:    containsID(users, fakeBotID),
+				Emoji: &discordgo.Emoji{Name: emoji},
+			})
+		}
+		json.NewEncoder(w).Encode(&reply)
+	case r.Method == http.MethodPatch && len(rest) == 0:
+		var edit struct {
+			Content *string `json:"content"`
+		}
+		json.NewDecoder(r.Body).Decode(&edit)
+		if edit.Content != nil {
+			msg.Content = *edit.Content
+		}
+		json.NewEncoder(w).Encode(msg)
+	case r.Method == http.MethodDelete && len(rest) == 0:
+		fd.removeMessages(channelID, messageID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && len(rest) == 1 && rest[0] == "threads":
+		fd.createThread(w, r, channelID, messageID)
+	case r.Method == http.MethodGet && len(rest) == 2 && rest[0] == "reactions":
+		after := r.URL.Query().Get("after")
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		users := []*discordgo.User{}
+		for _, id := range fd.reactions[msg.ID][rest[1]] {
+			if id > after && (limit == 0 || len(users) < limit) {
+				users = append(users, &discordgo.User{ID: id, Username: "user-" + id})
+			}
+		}
+		json.NewEncoder(w).Encode(users)
+	case len(rest) == 3 && rest[0] == "reactions":
+		emoji, userID := rest[1], rest[2]
+		if userID == "@me" {
+			userID = fakeBotID
+		}
+		if fd.reactions[msg.ID] == nil {
+			fd.reactions[msg.ID] = make(map[string][]string)
+		}
+		users := fd.reactions[msg.ID][emoji]
+		var kept []string
+		for _, id := range users {
+			if id != userID {
+				kept = append(kept, id)
+			}
+		}
+		if r.Method == http.MethodPut {
+			kept = append(kept, userID)
+			sort.Strings(kept)
+		}
+		fd.reactions[msg.ID][emoji] = kept
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// bulkDelete mimics POST /channels/{id}/messages/bulk-delete, which
+// refuses messages over two weeks old.
+func (fd *fakeDiscord) bulkDelete(w http.ResponseWriter, r *http.Request, channelID string) {
+	var body struct {
+		Messages []string `json:"messages"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	if len(body.Messages) < 2 || len(body.Messages) > 100 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Invalid Form Body", "code": 50035}`))
+		return
+	}
+
+	cutoff := time.Now().Add(-14 * 24 * time.Hour)
+	for _, msg := range fd.messages[channelID] {
+		if containsID(body.Messages, msg.ID) && msg.Timestamp.Before(cutoff) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "You can only bulk delete messages that are under 14 days old.", "code": 50034}`))
+			return
+		}
+	}
+	fd.bulkDeletes++
+	fd.removeMessages(channelID, body.Messages...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fd *fakeDiscord) removeMessages(channelID string, messageIDs ...string) {
+	var kept []*discordgo.Message
+	for _, msg := range fd.messages[channelID] {
+		if !containsID(messageIDs, msg.ID) {
+			kept = append(kept, msg)
+		}
+	}
+	fd.messages[channelID] = kept
+}
+
+// messageIDs returns the IDs of the messages left in channelID.
+func (fd *fakeDiscord) messageIDs(channelID string) []string {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	var ids []string
+	for _, msg := range fd.messages[channelID] {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+// findChannel returns the channel or thread with the given ID, or nil.
+func (fd *fakeDiscord) findChannel(channelID string) *discordgo.Channel {
+	for _, channels := range fd.channels {
+		for _, channel := range channels {
+			if channel.ID == channelID {
+				return channel
+			}
+		}
+	}
+	return nil
+}
+
+// channel returns a copy of the channel with the given ID, or nil.
+func (fd *fakeDiscord) channel(channelID string) *discordgo.Channel {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if channel := fd.findChannel(channelID); channel != nil {
+		c := *channel
+		return &c
+	}
+	return nil
+}
+
+func (fd *fakeDiscord) writeChannel(w http.ResponseWriter, channel *discordgo.Channel) {
+	if channel == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Unknown Channel", "code": 10003}`))
+		return
+	}
+	json.NewEncoder(w).Encode(channel)
+}
+
+// editChannel mimics PATCH /channels/{id} for the fields the tests change.
+func (fd *fakeDiscord) editChannel(w http.ResponseWriter, r *http.Request, channelID string) {
+	channel := fd.findChannel(channelID)
+	if channel == nil {
+		fd.writeChannel(w, nil)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var edit discordgo.ChannelEdit
+	json.Unmarshal(body, &edit)
+	// Topic and parent may be cleared with null, which ChannelEdit cannot
+	// tell from absent
+	var fields map[string]json.RawMessage
+	json.Unmarshal(body, &fields)
+	if _, ok := fields["topic"]; ok {
+		channel.Topic = edit.Topic
+	}
+	if _, ok := fields["parent_id"]; ok {
+		channel.ParentID = edit.ParentID
+	}
+	if edit.NSFW != nil {
+		channel.NSFW = *edit.NSFW
+	}
+	if edit.AvailableTags != nil {
+		for i := range *edit.AvailableTags {
+			if tag := &(*edit.AvailableTags)[i]; tag.ID == "" {
+				fd.nextID++
+				tag.ID = strconv.Itoa(fd.nextID)
+			}
+		}
+		channel.AvailableTags = *edit.AvailableTags
+	}
+	if meta := channel.ThreadMetadata; meta != nil {
+		if edit.Archived != nil {
+			meta.Archived = *edit.Archived
+			meta.ArchiveTimestamp = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		}
+		if edit.Locked != nil {
+			meta.Locked = *edit.Locked
+		}
+	}
+	fd.writeChannel(w, channel)
+}
+
+// addMember adds a member to guildID with the given roles. Members must be
+// added in user ID order.
+func (fd *fakeDiscord) addMember(guildID, userID, username, nick string, roleIDs ...string) *discordgo.Member {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	member := &discordgo.Member{
+		GuildID:  guildID,
+		User:     &discordgo.User{ID: userID, Username: username},
+		Nick:     nick,
+		Roles:    roleIDs,
+		JoinedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	fd.members[guildID] = append(fd.members[guildID], member)
+	return member
+}
+
+// addRole adds a role to guildID.
+func (fd *fakeDiscord) addRole(guildID, roleID, name string) *discordgo.Role {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	role := &discordgo.Role{ID: roleID, Name: name}
+	fd.roles[guildID] = append(fd.roles[guildID], role)
+	return role
+}
+
+// findRole returns the role in guildID with the given ID, or nil.
+func (fd *fakeDiscord) findRole(guildID, roleID string) *discordgo.Role {
+	for _, role := range fd.roles[guildID] {
+		if role.ID == roleID {
+			return role
+		}
+	}
+	return nil
+}
+
+// role returns a copy of the role in guildID with the given ID, or nil.
+func (fd *fakeDiscord) role(guildID, roleID string) *discordgo.Role {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if role := fd.findRole(guildID, roleID); role != nil {
+		r := *role
+		return &r
+	}
+	return nil
+}
+
+// memberRoles returns the IDs of the roles userID holds in guildID.
+func (fd *fakeDiscord) memberRoles(guildID, userID string) []string {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	for _, member := range fd.members[guildID] {
+		if member.User.ID == userID {
+			return append([]string(nil), member.Roles...)
+		}
+	}
+	return nil
+}
+
+// editRole mimics PATCH /guilds/{id}/roles/{id}, or creating a role if
+// roleID is empty.
+func (fd *fakeDiscord) editRole(w http.ResponseWriter, r *http.Request, guildID, roleID string) {
+	role := &discordgo.Role{}
+	if roleID == "" {
+		fd.nextID++
+		role.ID = strconv.Itoa(fd.nextID)
+		role.Position = 1
+		fd.roles[guildID] = append(fd.roles[guildID], role)
+	} else if role = fd.findRole(guildID, roleID); role == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Unknown Role", "code": 10011}`))
+		return
+	}
+
+	var params discordgo.RoleParams
+	json.NewDecoder(r.Body).Decode(&params)
+	if params.Name != "" {
+		role.Name = params.Name
+	}
+	if params.Color != nil {
+		role.Color = *params.Color
+	}
+	if params.Permissions != nil {
+		role.Permissions = *params.Permissions
+	}
+	if params.Hoist != nil {
+		role.Hoist = *params.Hoist
+	}
+	if params.Mentionable != nil {
+		role.Mentionable = *params.Mentionable
+	}
+	json.NewEncoder(w).Encode(role)
+}
+
+func (fd *fakeDiscord) deleteRole(w http.ResponseWriter, guildID, roleID string) {
+	roles := fd.roles[guildID]
+	for i := range roles {
+		if roles[i].ID == roleID {
+			fd.roles[guildID] = append(roles[:i:i], roles[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// setMemberRole mimics giving a member a role, or taking it away.
+func (fd *fakeDiscord) setMemberRole(w http.ResponseWriter, guildID, userID, roleID string, add bool) {
+	for _, member := range fd.members[guildID] {
+		if member.User.ID != userID {
+			continue
+		}
+		var kept []string
+		for _, id := range member.Roles {
+			if id != roleID {
+				kept = append(kept, id)
+			}
+		}
+		if add {
+			kept = append(kept, roleID)
+		}
+		member.Roles = kept
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// listMembers mimics GET /guilds/{id}/members, paging by user ID.
+func (fd *fakeDiscord) listMembers(w http.ResponseWriter, r *http.Request, guildID string) {
+	after := r.URL.Query().Get("after")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	page := []*discordgo.Member{}
+	for _, member := range fd.members[guildID] {
+		if len(page) == limit {
+			break
+		}
+		if after == "" || member.User.ID > after {
+			page = append(page, member)
+		}
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+// searchMembers mimics GET /guilds/{id}/members/search.
+func (fd *fakeDiscord) searchMembers(w http.ResponseWriter, r *http.Request, guildID string) {
+	query := strings.ToLower(r.URL.Query().Get("query"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	found := []*discordgo.Member{}
+	for _, member := range fd.members[guildID] {
+		if len(found) == limit {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(member.User.Username), query) || strings.HasPrefix(strings.ToLower(member.Nick), query) {
+			found = append(found, member)
+		}
+	}
+	json.NewEncoder(w).Encode(found)
+}
+
+func (fd *fakeDiscord) getGuild(w http.ResponseWriter, guildID string) {
+	owner, ok := fd.owners[guildID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Unknown Guild", "code": 10004}`))
+		return
+	}
+	json.NewEncoder(w).Encode(&discordgo.Guild{ID: guildID, Name: "guild-" + guildID, OwnerID: owner})
+}
+
+// setOwner makes userID the owner of guildID.
+func (fd *fakeDiscord) setOwner(guildID, userID string) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.owners[guildID] = userID
+}
+
+func (fd *fakeDiscord) getMember(w http.ResponseWriter, guildID, userID string) {
+	for _, member := range fd.members[guildID] {
+		if member.User.ID == userID {
+			json.NewEncoder(w).Encode(member)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Unknown Member", "code": 10007}`))
+}
+
+// member returns a copy of userID's membership of guildID, or nil.
+func (fd *fakeDiscord) member(guildID, userID string) *discordgo.Member {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	for _, member := range fd.members[guildID] {
+		if member.User.ID == userID {
+			m := *member
+			return &m
+		}
+	}
+	return nil
+}
+
+// editMember mimics PATCH /guilds/{id}/members/{id} for nicknames and
+// timeouts.
+func (fd *fakeDiscord) editMember(w http.ResponseWriter, r *http.Request, guildID, userID string) {
+	var fields map[string]json.RawMessage
+	json.NewDecoder(r.Body).Decode(&fields)
+
+	for _, member := range fd.members[guildID] {
+		if member.User.ID != userID {
+			continue
+		}
+		if nick, ok := fields["nick"]; ok {
+			json.Unmarshal(nick, &member.Nick)
+		}
+		if until, ok := fields["communication_disabled_until"]; ok {
+			member.CommunicationDisabledUntil = nil
+			json.Unmarshal(until, &member.CommunicationDisabledUntil)
+		}
+		json.NewEncoder(w).Encode(member)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Unknown Member", "code": 10007}`))
+}
+
+// addBan bans userID from guildID. Bans must be added in user ID order.
+func (fd *fakeDiscord) addBan(guildID, userID, reason string) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.bans[guildID] = append(fd.bans[guildID], &discordgo.GuildBan{
+		User:   &discordgo.User{ID: userID, Username: "user-" + userID},
+		Reason: reason,
+	})
+}
+
+// bannedIDs returns the IDs of the users banned from guildID.
+func (fd *fakeDiscord) bannedIDs(guildID string) []string {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	var ids []string
+	for _, ban := range fd.bans[guildID] {
+		ids = append(ids, ban.User.ID)
+	}
+	return ids
+}
+
+// listBans mimics GET /guilds/{id}/bans, paging by user ID.
+func (fd *fakeDiscord) listBans(w http.ResponseWriter, r *http.Request, guildID string) {
+	after := r.URL.Query().Get("after")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	page := []*discordgo.GuildBan{}
+	for _, ban := range fd.bans[guildID] {
+		if len(page) == limit {
+			break
+		}
+		if after == "" || ban.User.ID > after {
+			page = append(page, ban)
+		}
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+func (fd *fakeDiscord) getBan(w http.ResponseWriter, guildID, userID string) {
+	for _, ban := range fd.bans[guildID] {
+		if ban.User.ID == userID {
+			json.NewEncoder(w).Encode(ban)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Unknown Ban", "code": 10026}`))
+}
+
+func (fd *fakeDiscord) deleteBan(w http.ResponseWriter, guildID, userID string) {
+	bans := fd.bans[guildID]
+	for i := range bans {
+		if bans[i].User.ID == userID {
+			fd.bans[guildID] = append(bans[:i:i], bans[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Unknown Ban", "code": 10026}`))
+}
+
+// createChannel mimics POST /guilds/{id}/channels.
+func (fd *fakeDiscord) createChannel(w http.ResponseWriter, r *http.Request, guildID string) {
+	var data discordgo.GuildChannelCreateData
+	json.NewDecoder(r.Body).Decode(&data)
+
+	fd.nextID++
+	channel := &discordgo.Channel{
+		ID:               strconv.Itoa(fd.nextID),
+		GuildID:          guildID,
+		Name:             data.Name,
+		Type:             data.Type,
+		Topic:            data.Topic,
+		ParentID:         data.ParentID,
+		NSFW:             data.NSFW,
+		RateLimitPerUser: data.RateLimitPerUser,
+		Position:         data.Position,
+	}
+	fd.channels[guildID] = append(fd.channels[guildID], channel)
+	fd.writeChannel(w, channel)
+}
+
+// deleteChannel mimics DELETE /channels/{id}, returning the deleted
+// channel.
+func (fd *fakeDiscord) deleteChannel(w http.ResponseWriter, channelID string) {
+	channel := fd.findChannel(channelID)
+	if channel == nil {
+		fd.writeChannel(w, nil)
+		return
+	}
+	channels := fd.channels[channel.GuildID]
+	for i := range channels {
+		if channels[i].ID == channelID {
+			fd.channels[channel.GuildID] = append(channels[:i:i], channels[i+1:]...)
+			break
+		}
+	}
+	fd.writeChannel(w, channel)
+}
+
+// reorderChannels mimics PATCH /guilds/{id}/channels.
+func (fd *fakeDiscord) reorderChannels(w http.ResponseWriter, r *http.Request) {
+	var positions []discord.ChannelPosition
+	json.NewDecoder(r.Body).Decode(&positions)
+	for _, p := range positions {
+		channel := fd.findChannel(p.ID)
+		if channel == nil {
+			fd.writeChannel(w, nil)
+			return
+		}
+		channel.Position = p.Position
+		if p.ParentID != "" {
+			channel.ParentID = p.ParentID
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createThread mimics starting a thread in channelID, from messageID if it
+// is set.
+func (fd *fakeDiscord) createThread(w http.ResponseWriter, r *http.Request, channelID, messageID string) {
+	parent := fd.findChannel(channelID)
+	if parent == nil {
+		fd.writeChannel(w, nil)
+		return
+	}
+
+	var start struct {
+		discordgo.ThreadStart
+		Message *discordgo.MessageSend `json:"message"`
+	}
+	json.NewDecoder(r.Body).Decode(&start)
+
+	thread := &discordgo.Channel{
+		ID:       messageID,
+		GuildID:  parent.GuildID,
+		ParentID: channelID,
+		Name:     start.Name,
+		Type:     start.Type,
+		OwnerID:  fakeBotID,
+		ThreadMetadata: &discordgo.ThreadMetadata{
+			AutoArchiveDuration: start.AutoArchiveDuration,
+		},
+		AppliedTags: start.AppliedTags,
+	}
+	if messageID == "" {
+		fd.nextID++
+		thread.ID = strconv.Itoa(fd.nextID)
+	} else {
+		thread.Type = discordgo.ChannelTypeGuildPublicThread
+	}
+
+	// Forum posts start with a message of their own
+	if start.Message != nil {
+		thread.Type = discordgo.ChannelTypeGuildPublicThread
+		fd.messages[thread.ID] = append(fd.messages[thread.ID], &discordgo.Message{
+			ID:        thread.ID,
+			ChannelID: thread.ID,
+			Content:   start.Message.Content,
+			Author:    &discordgo.User{ID: fakeBotID, Username: "bot"},
+		})
+	}
+	fd.channels[parent.GuildID] = append(fd.channels[parent.GuildID], thread)
+	fd.threadMembers[thread.ID] = []string{fakeBotID}
+	json.NewEncoder(w).Encode(thread)
+}
+
+// addThread adds a thread under parentID, archived at the given time if it
+// is not zero.
+func (fd *fakeDiscord) addThread(parentID, threadID string, private bool, archivedAt time.Time) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	parent := fd.findChannel(parentID)
+	thread := &discordgo.Channel{
+		ID:       threadID,
+		GuildID:  parent.GuildID,
+		ParentID: parentID,
+		Name:     "thread-" + threadID,
+		Type:     discordgo.ChannelTypeGuildPublicThread,
+		ThreadMetadata: &discordgo.ThreadMetadata{
+			Archived:         !archivedAt.IsZero(),
+			ArchiveTimestamp: archivedAt,
+		},
+	}
+	if private {
+		thread.Type = discordgo.ChannelTypeGuildPrivateThread
+	}
+	fd.channels[parent.GuildID] = append(fd.channels[parent.GuildID], thread)
+}
+
+// addForum adds a forum channel offering the given tags, with IDs 901,
+// 902, ...
+func (fd *fakeDiscord) addForum(guildID, channelID string, tags ...string) {
+	fd.addChannel(guildID, channelID, discordgo.ChannelTypeGuildForum, false)
+
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	forum := fd.findChannel(channelID)
+	for i, name := range tags {
+		forum.AvailableTags = append(forum.AvailableTags, discordgo.ForumTag{ID: strconv.Itoa(901 + i), Name: name})
+	}
+}
+
+// tagThread applies tags to a thread by ID.
+func (fd *fakeDiscord) tagThread(threadID string, tagIDs ...string) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.findChannel(threadID).AppliedTags = tagIDs
+}
+
+// guildChannels lists a guild's channels; as on Discord, threads are
+// left out.
+func (fd *fakeDiscord) guildChannels(w http.ResponseWriter, guildID string) {
+	channels := []*discordgo.Channel{}
+	for _, channel := range fd.channels[guildID] {
+		if !channel.IsThread() {
+			channels = append(channels, channel)
+		}
+	}
+	json.NewEncoder(w).Encode(channels)
+}
+
+func (fd *fakeDiscord) activeThreads(w http.ResponseWriter, guildID string) {
+	list := discordgo.ThreadsList{Threads: []*discordgo.Channel{}}
+	for _, channel := range fd.channels[guildID] {
+		if channel.IsThread() && !channel.ThreadMetadata.Archived {
+			list.Threads = append(list.Threads, channel)
+		}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// archivedThreads mimics the archived thread listings, most recently
+// archived first.
+func (fd *fakeDiscord) archivedThreads(w http.ResponseWriter, r *http.Request, channelID string, private bool) {
+	parent := fd.findChannel(channelID)
+	if parent == nil {
+		fd.writeChannel(w, nil)
+		return
+	}
+
+	var before time.Time
+	if b := r.URL.Query().Get("before"); b != "" {
+		before, _ = time.Parse(time.RFC3339, b)
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	var threads []*discordgo.Channel
+	for _, channel := range fd.channels[parent.GuildID] {
+		if channel.ParentID != channelID || !channel.IsThread() || !channel.ThreadMetadata.Archived {
+			continue
+		}
+		if private != (channel.Type == discordgo.ChannelTypeGuildPrivateThread) {
+			continue
+		}
+		if !before.IsZero() && !channel.ThreadMetadata.ArchiveTimestamp.Before(before) {
+			continue
+		}
+		threads = append(threads, channel)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		return threads[i].ThreadMetadata.ArchiveTimestamp.After(threads[j].ThreadMetadata.ArchiveTimestamp)
+	})
+
+	list := discordgo.ThreadsList{Threads: threads}
+	if limit > 0 && len(threads) > limit {
+		list.Threads, list.HasMore = threads[:limit], true
+	}
+	if list.Threads == nil {
+		list.Threads = []*discordgo.Channel{}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// isThreadMember reports whether userID is a member of threadID.
+func (fd *fakeDiscord) isThreadMember(threadID, userID string) bool {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return containsID(fd.threadMembers[threadID], userID)
+}
+
+// react records userID reacting to a message with emoji.
+func (fd *fakeDiscord) react(messageID, emoji string, userIDs ...string) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if fd.reactions[messageID] == nil {
+		fd.reactions[messageID] = make(map[string][]string)
+	}
+	fd.reactions[messageID][emoji] = append(fd.reactions[messageID][emoji], userIDs...)
+	sort.Strings(fd.reactions[messageID][emoji])
+}
+
+func (fd *fakeDiscord) isPinned(messageID string) bool {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.pinned[messageID]
+}
+
+func containsID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// createMessage mimics POST /channels/{id}/messages, accepting both JSON
+// and multipart bodies.
+func (fd *fakeDiscord) createMessage(w http.ResponseWriter, r *http.Request, channelID string) {
+	sent := sentMessage{ChannelID: channelID, Files: make(map[string][]byte)}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(part)
+			if part.FormName() == "payload_json" {
+				json.Unmarshal(data, &sent.Payload)
+			} else {
+				sent.Files[part.FileName()] = data
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&sent.Payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fd.sent = append(fd.sent, sent)
+
+	json.NewEncoder(w).Encode(&discordgo.Message{
+		ID:        strconv.Itoa(1000 + len(fd.sent)),
+		ChannelID: channelID,
+		Content:   sent.Payload.Content,
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Author:    &discordgo.User{ID: "1", Username: "bot"},
+	})
+}
+
+// page mimics GET /channels/{id}/messages: at most limit messages, newest
+// first, selected by the before, after or around cursor.
+func (fd *fakeDiscord) page(channelID string, query map[string][]string) []*discordgo.Message {
+	get := func(key string) int {
+		if v, ok := query[key]; ok {
+			n, _ := strconv.Atoi(v[0])
+			return n
+		}
+		return 0
+	}
+
+	limit := get("limit")
+	if limit == 0 {
+		limit = 50
+	}
+
+	all := fd.messages[channelID]
+	var selected []*discordgo.Message
+	switch {
+	case get("around") != 0:
+		around := get("around")
+		sort.Slice(all, func(i, j int) bool { return id(all[i]) < id(all[j]) })
+		for i, msg := range all {
+			if id(msg) >= around {
+				start := i - limit/2
+				if start < 0 {
+					start = 0
+				}
+				end := start + limit
+				if end > len(all) {
+					end = len(all)
+				}
+				selected = append(selected, all[start:end]...)
+				break
+			}
+		}
+	case get("after") != 0:
+		// Discord returns the messages immediately after the cursor
+		for _, msg := range all {
+			if id(msg) > get("after") && len(selected) < limit {
+				selected = append(selected, msg)
+			}
+		}
+	default:
+		before := get("before")
+		for i := len(all) - 1; i >= 0 && len(selected) < limit; i-- {
+			if before == 0 || id(all[i]) < before {
+				selected = append(selected, all[i])
+			}
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return id(selected[i]) > id(selected[j]) })
+	if selected == nil {
+		selected = []*discordgo.Message{}
+	}
+	return selected
+}
+
+func id(msg *discordgo.Message) int {
+	n, _ := strconv.Atoi(msg.ID)
+	return n
+}
+
+func newTestDiscordClient(t *testing.T) *discord.Client {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	client, err := discord.NewClient("test-token", logger)
+	require.NoError(t, err)
+	return client
+}
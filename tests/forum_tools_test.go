@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChannelInfoNamesTypeAndTags(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addForum("9", "1", "bug", "feature")
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		StructuredContent mcp.DiscordChannel `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "get_channel_info", map[string]interface{}{"channel_id": "1"}), &result)
+	assert.Equal(t, int(discordgo.ChannelTypeGuildForum), result.StructuredContent.Type)
+	assert.Equal(t, "forum", result.StructuredContent.TypeName)
+	assert.Equal(t, []mcp.DiscordForumTag{{ID: "901", Name: "bug"}, {ID: "902", Name: "feature"}}, result.StructuredContent.AvailableTags)
+}
+
+func TestListForumPosts(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addForum("9", "1", "bug", "feature")
+	fd.addThread("1", "11", false, time.Time{})
+	fd.addThread("1", "12", false, time.Time{})
+	fd.addThread("1", "13", false, time.Time{})
+	fd.tagThread("11", "901")
+	fd.tagThread("12", "902", "901")
+	fd.tagThread("13", "902")
+	fd.addChannel("9", "2", discordgo.ChannelTypeGuildText, false)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var all threadListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_forum_posts", map[string]interface{}{"channel_id": "1"}), &all)
+	require.Len(t, all.StructuredContent.Threads, 3)
+	assert.Equal(t, []string{"feature", "bug"}, all.StructuredContent.Threads[1].AppliedTags)
+
+	var bugs threadListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_forum_posts", map[string]interface{}{
+		"channel_id": "1", "tags": []interface{}{"BUG"},
+	}), &bugs)
+	assert.Equal(t, []string{"11", "12"}, threadIDs(bugs.StructuredContent))
+
+	reply := callToolRPC(t, ts.URL, sessionID, "list_forum_posts", map[string]interface{}{"channel_id": "2"})
+	require.NotNil(t, reply.Error)
+	assert.Contains(t, reply.Error.Message, "not a forum")
+}
+
+func TestCreateForumPost(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addForum("9", "1", "bug", "feature")
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var post threadResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "create_forum_post", map[string]interface{}{
+		"channel_id": "1",
+		"title":      "Crash on startup",
+		"content":    "Steps to reproduce: ...",
+		"tags":       []interface{}{"bug"},
+	}), &post)
+	assert.Equal(t, "Crash on startup", post.StructuredContent.Name)
+	assert.Equal(t, []string{"bug"}, post.StructuredContent.AppliedTags)
+
+	var messages struct {
+		StructuredContent mcp.MessageListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "get_messages", map[string]interface{}{"channel_id": post.StructuredContent.ID}), &messages)
+	require.Len(t, messages.StructuredContent.Messages, 1)
+	assert.Equal(t, "Steps to reproduce: ...", messages.StructuredContent.Messages[0].Content)
+
+	reply := callToolRPC(t, ts.URL, sessionID, "create_forum_post", map[string]interface{}{
+		"channel_id": "1", "title": "x", "content": "y", "tags": []interface{}{"question"},
+	})
+	assert.NotNil(t, reply.Error)
+}
+
+func TestManageForumTags(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addForum("9", "1", "bug", "feature")
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		StructuredContent mcp.DiscordChannel `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "manage_forum_tags", map[string]interface{}{
+		"channel_id": "1",
+		"add": []interface{}{
+			map[string]interface{}{"name": "question", "emoji": "❓"},
+			map[string]interface{}{"name": "Bug", "moderated": true},
+		},
+		"remove": []interface{}{"902"},
+	}), &result)
+
+	tags := result.StructuredContent.AvailableTags
+	require.Len(t, tags, 2)
+	assert.Equal(t, mcp.DiscordForumTag{ID: "901", Name: "bug", Moderated: true}, tags[0])
+	assert.Equal(t, "question", tags[1].Name)
+	assert.Equal(t, "❓", tags[1].Emoji)
+	assert.NotEmpty(t, tags[1].ID)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/ReesavGupta/discord-mcp-server/internal/index"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIndex(t *testing.T, channels ...string) *index.Index {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	idx, err := index.Open(filepath.Join(t.TempDir(), "index.db"), channels, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+func indexedMessage(id int, channelID, content string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:        strconv.Itoa(id),
+		ChannelID: channelID,
+		GuildID:   "9",
+		Content:   content,
+		Timestamp: time.Date(2024, 1, 1, 0, id, 0, 0, time.UTC),
+		Author:    &discordgo.User{ID: "42", Username: "tester"},
+	}
+}
+
+func searchIDs(t *testing.T, idx *index.Index, query string, opts index.SearchOptions) []string {
+	results, err := idx.Search(query, opts)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Message.ID)
+	}
+	return ids
+}
+
+func TestIndexBooleanQueries(t *testing.T) {
+	idx := newTestIndex(t)
+	require.NoError(t, idx.Add(
+		indexedMessage(1, "1", "The deploy failed on staging"),
+		indexedMessage(2, "1", "deploy succeeded on production"),
+		indexedMessage(3, "2", "Staging database is down"),
+		indexedMessage(4, "2", "rollback the failed deploy"),
+	))
+
+	opts := index.SearchOptions{}
+	assert.ElementsMatch(t, []string{"1", "4"}, searchIDs(t, idx, "deploy failed", opts))
+	assert.ElementsMatch(t, []string{"1", "2", "4", "3"}, searchIDs(t, idx, "deploy OR database", opts))
+	assert.ElementsMatch(t, []string{"2", "4"}, searchIDs(t, idx, "deploy -staging", opts))
+	assert.ElementsMatch(t, []string{"2", "4"}, searchIDs(t, idx, "deploy NOT staging", opts))
+	assert.Equal(t, []string{"4"}, searchIDs(t, idx, `"failed deploy"`, opts))
+	assert.ElementsMatch(t, []string{"1", "2", "4"}, searchIDs(t, idx, "depl*", opts))
+	assert.Equal(t, []string{"3"}, searchIDs(t, idx, "staging", index.SearchOptions{ChannelIDs: []string{"2"}}))
+
+	_, err := idx.Search("-deploy", opts)
+	assert.Error(t, err)
+}
+
+func TestIndexRanking(t *testing.T) {
+	idx := newTestIndex(t)
+	require.NoError(t, idx.Add(
+		indexedMessage(1, "1", "outage outage outage in the eu region"),
+		indexedMessage(2, "1", "a very long message that mentions an outage once among many other unrelated words here"),
+		indexedMessage(3, "1", "nothing to see"),
+	))
+
+	assert.Equal(t, []string{"1", "2"}, searchIDs(t, idx, "outage", index.SearchOptions{}))
+}
+
+func TestIndexLimitsRankedResults(t *testing.T) {
+	idx := newTestIndex(t)
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, idx.Add(indexedMessage(i, strconv.Itoa(2-i%2), "release notes")))
+	}
+
+	// Equal scores rank newest first, and only messages passing the
+	// filters count towards the limit
+	assert.Equal(t, []string{"6", "5"}, searchIDs(t, idx, "release", index.SearchOptions{Limit: 2}))
+	assert.Equal(t, []string{"5", "3"}, searchIDs(t, idx, "release", index.SearchOptions{ChannelIDs: []string{"1"}, Limit: 2}))
+}
+
+func TestIndexFollowsMessageEvents(t *testing.T) {
+	idx := newTestIndex(t, "1")
+
+	idx.HandleEvent(discord.MessageEvent{
+		Type:      discord.MessageCreated,
+		ChannelID: "1",
+		Message:   indexedMessage(1, "1", "first draft"),
+	})
+	idx.HandleEvent(discord.MessageEvent{
+		Type:      discord.MessageCreated,
+		ChannelID: "2",
+		Message:   indexedMessage(2, "2", "first draft elsewhere"),
+	})
+	assert.Equal(t, []string{"1"}, searchIDs(t, idx, "draft", index.SearchOptions{}))
+
+	// Edits replace the indexed content and keep the author
+	edited := indexedMessage(1, "1", "final version")
+	edited.Author = nil
+	idx.HandleEvent(discord.MessageEvent{Type: discord.MessageUpdated, ChannelID: "1", Message: edited})
+	assert.Empty(t, searchIDs(t, idx, "draft", index.SearchOptions{}))
+	assert.Equal(t, []string{"1"}, searchIDs(t, idx, "final", index.SearchOptions{AuthorID: "42"}))
+
+	idx.HandleEvent(discord.MessageEvent{Type: discord.MessageDeleted, ChannelID: "1", MessageIDs: []string{"1"}})
+	assert.Empty(t, searchIDs(t, idx, "final", index.SearchOptions{}))
+
+	count, err := idx.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestIndexTruncatesLongWordsByRune(t *testing.T) {
+	idx := newTestIndex(t)
+	// One ASCII letter puts byte 64 in the middle of a two-byte rune
+	word := "x" + strings.Repeat("ä", 80)
+	require.NoError(t, idx.Add(indexedMessage(1, "1", "checksum "+word)))
+
+	assert.Equal(t, []string{"1"}, searchIDs(t, idx, word, index.SearchOptions{}))
+	// Words agreeing in their first 64 runes are the same term
+	assert.Equal(t, []string{"1"}, searchIDs(t, idx, "x"+strings.Repeat("ä", 63)+"ö", index.SearchOptions{}))
+	assert.Equal(t, []string{"1"}, searchIDs(t, idx, "x"+strings.Repeat("ä", 40)+"*", index.SearchOptions{}))
+	// Cutting at 64 bytes would keep only the lead byte of the 32nd rune,
+	// which ä and ö share
+	assert.Empty(t, searchIDs(t, idx, "x"+strings.Repeat("ä", 31)+strings.Repeat("ö", 40), index.SearchOptions{}))
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memberListResult struct {
+	StructuredContent mcp.MemberListResult `json:"structuredContent"`
+}
+
+func memberIDs(members []mcp.DiscordMember) []string {
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	return ids
+}
+
+func TestListMembersPaginates(t *testing.T) {
+	fd := newFakeDiscord(t)
+	for i := 0; i < 1500; i++ {
+		fd.addMember("9", fmt.Sprintf("1000000000000%05d", i), fmt.Sprintf("user%d", i), "")
+	}
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	// 1200 members take two requests of at most 1000
+	var first memberListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_members", map[string]interface{}{"limit": 1200}), &first)
+	require.Equal(t, 1200, first.StructuredContent.Count)
+	assert.Equal(t, "100000000000000000", first.StructuredContent.Members[0].UserID)
+	assert.Equal(t, "100000000000001199", first.StructuredContent.NextAfter)
+
+	var rest memberListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_members", map[string]interface{}{
+		"limit": 1200,
+		"after": first.StructuredContent.NextAfter,
+	}), &rest)
+	require.Equal(t, 300, rest.StructuredContent.Count)
+	assert.Equal(t, "100000000000001200", rest.StructuredContent.Members[0].UserID)
+	assert.Empty(t, rest.StructuredContent.NextAfter)
+}
+
+func TestSearchMembers(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addRole("9", "500", "Moderator")
+	fd.addMember("9", "100000000000000001", "alex", "", "500")
+	fd.addMember("9", "100000000000000002", "bob", "Alice")
+	fd.addMember("9", "100000000000000003", "carol", "")
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	var result memberListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "search_members", map[string]interface{}{"query": "@al"}), &result)
+	assert.Equal(t, "al", result.StructuredContent.Query)
+	assert.Equal(t, []string{"100000000000000001", "100000000000000002"}, memberIDs(result.StructuredContent.Members))
+	assert.Equal(t, []mcp.DiscordRef{{ID: "500", Name: "Moderator"}}, result.StructuredContent.Members[0].Roles)
+	assert.Equal(t, "Alice", result.StructuredContent.Members[1].DisplayName)
+}
+
+func TestGetMember(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addRole("9", "500", "Moderator")
+	fd.addRole("9", "501", "Oncall")
+	member := fd.addMember("9", "100000000000000001", "alex", "Alex (SRE)", "500", "501")
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	member.CommunicationDisabledUntil = &until
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		StructuredContent mcp.DiscordMember `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "get_member", map[string]interface{}{"user_id": "<@!100000000000000001>"}), &result)
+	m := result.StructuredContent
+	assert.Equal(t, "alex", m.Username)
+	assert.Equal(t, "Alex (SRE)", m.Nickname)
+	assert.Equal(t, "2024-01-01T00:00:00Z", m.JoinedAt)
+	assert.Equal(t, []mcp.DiscordRef{{ID: "500", Name: "Moderator"}, {ID: "501", Name: "Oncall"}}, m.Roles)
+	assert.Equal(t, until.Format(time.RFC3339), m.TimedOutUntil)
+
+	reply := callToolRPC(t, ts.URL, sessionID, "get_member", map[string]interface{}{"user_id": "alex"})
+	require.NotNil(t, reply.Error)
+	assert.Contains(t, reply.Error.Message, "invalid user_id")
+}
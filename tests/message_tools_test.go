@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditMessage(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 10, 1, func(i int) string { return "Deploy: pending" })
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		StructuredContent mcp.SendMessageResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "edit_message", map[string]interface{}{
+		"channel_id": "1",
+		"message_id": "10",
+		"content":    "Deploy: done",
+	}), &result)
+	assert.Equal(t, "Deploy: done", result.StructuredContent.Message.Content)
+
+	reply := callToolRPC(t, ts.URL, sessionID, "edit_message", map[string]interface{}{"channel_id": "1", "message_id": "10"})
+	assert.NotNil(t, reply.Error)
+}
+
+func TestPinAndReactToMessage(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 10, 1, func(i int) string { return "Release v2" })
+	fd.react("10", "🎉", "20", "21", "22")
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	call := func(name string, args map[string]interface{}) {
+		args["channel_id"], args["message_id"] = "1", "10"
+		require.Nil(t, callToolRPC(t, ts.URL, sessionID, name, args).Error, name)
+	}
+
+	call("pin_message", map[string]interface{}{})
+	assert.True(t, fd.isPinned("10"))
+	call("unpin_message", map[string]interface{}{})
+	assert.False(t, fd.isPinned("10"))
+
+	call("add_reaction", map[string]interface{}{"emoji": "👍"})
+	call("add_reaction", map[string]interface{}{"emoji": "🎉"})
+	call("remove_reaction", map[string]interface{}{"emoji": "🎉", "user_id": "21"})
+
+	var summary struct {
+		StructuredContent mcp.ReactionListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_reactions", map[string]interface{}{
+		"channel_id": "1", "message_id": "10",
+	}), &summary)
+	assert.ElementsMatch(t, []mcp.ReactionCount{
+		{Emoji: "👍", Count: 1, Me: true},
+		{Emoji: "🎉", Count: 3, Me: true},
+	}, summary.StructuredContent.Reactions)
+
+	var users struct {
+		StructuredContent mcp.ReactionListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_reactions", map[string]interface{}{
+		"channel_id": "1", "message_id": "10", "emoji": "🎉", "limit": 2,
+	}), &users)
+	var ids []string
+	for _, u := range users.StructuredContent.Users {
+		ids = append(ids, u.ID)
+	}
+	assert.Equal(t, []string{"1", "20"}, ids)
+	assert.Equal(t, "20", users.StructuredContent.NextAfter)
+}
+
+func TestEmojiAPIName(t *testing.T) {
+	for input, want := range map[string]string{
+		"👍":              "👍",
+		"<:party:123>":   "party:123",
+		"<a:party:123>":  "party:123",
+		"party:123":      "party:123",
+		" <:party:123> ": "party:123",
+	} {
+		assert.Equal(t, want, discord.EmojiAPIName(input), fmt.Sprintf("%q", input))
+	}
+}
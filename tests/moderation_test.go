@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/config"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type moderationResult struct {
+	StructuredContent mcp.ModerationResult `json:"structuredContent"`
+}
+
+// Permissions for the bot's role: moderatorPermissions allow every
+// moderation action and dryRunPermissions all but timeouts.
+const (
+	moderatorPermissions = dryRunPermissions | discordgo.PermissionModerateMembers | discordgo.PermissionManageNicknames
+	dryRunPermissions    = discordgo.PermissionManageMessages | discordgo.PermissionKickMembers | discordgo.PermissionBanMembers
+)
+
+func TestTimeoutUser(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: moderatorPermissions})
+
+	before := time.Now()
+	var result moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":   "timeout_user",
+		"user_id":  alexID,
+		"duration": "2h",
+		"reason":   "Spamming links",
+	}), &result)
+	until, err := time.Parse(time.RFC3339, result.StructuredContent.Until)
+	require.NoError(t, err)
+	assert.WithinDuration(t, before.Add(2*time.Hour), until, 2*time.Second)
+	require.NotNil(t, fd.member("9", alexID).CommunicationDisabledUntil)
+	assert.True(t, fd.member("9", alexID).CommunicationDisabledUntil.Equal(until))
+	assert.Equal(t, []string{"Spamming links"}, fd.auditLog())
+
+	var removed moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":  "remove_timeout",
+		"user_id": alexID,
+	}), &removed)
+	assert.Empty(t, removed.StructuredContent.Until)
+	assert.Nil(t, fd.member("9", alexID).CommunicationDisabledUntil)
+}
+
+func TestTimeoutUserRejectsInvalidDurations(t *testing.T) {
+	_, url, sessionID := newTestGuild(t, testGuild{botPermissions: moderatorPermissions})
+
+	for _, duration := range []string{"", "soon", "-5m", "29d"} {
+		reply := callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+			"action":   "timeout_user",
+			"user_id":  alexID,
+			"duration": duration,
+		})
+		require.NotNil(t, reply.Error, duration)
+		assert.Contains(t, reply.Error.Message, "duration", duration)
+	}
+}
+
+func TestListBansAndUnban(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: moderatorPermissions})
+	fd.addBan("9", "100000000000000011", "Raid")
+	fd.addBan("9", "100000000000000012", "")
+	fd.addBan("9", "100000000000000013", "Scam links")
+
+	var page moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action": "list_bans",
+		"limit":  2,
+	}), &page)
+	require.Len(t, page.StructuredContent.Bans, 2)
+	assert.Equal(t, "Raid", page.StructuredContent.Bans[0].Reason)
+	assert.Equal(t, "100000000000000012", page.StructuredContent.NextAfter)
+
+	var rest moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action": "list_bans",
+		"limit":  2,
+		"after":  page.StructuredContent.NextAfter,
+	}), &rest)
+	require.Len(t, rest.StructuredContent.Bans, 1)
+	assert.Equal(t, "100000000000000013", rest.StructuredContent.Bans[0].User.ID)
+	assert.Empty(t, rest.StructuredContent.NextAfter)
+
+	var unbanned moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":  "unban_user",
+		"user_id": "100000000000000011",
+		"reason":  "Appeal accepted",
+	}), &unbanned)
+	assert.Equal(t, "unban_user", unbanned.StructuredContent.Action)
+	assert.Equal(t, []string{"100000000000000012", "100000000000000013"}, fd.bannedIDs("9"))
+	assert.Equal(t, []string{"Appeal accepted"}, fd.auditLog())
+}
+
+func TestSetNickname(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: moderatorPermissions})
+
+	var result moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":   "set_nickname",
+		"user_id":  alexID,
+		"nickname": "alex (please read #rules)",
+	}), &result)
+	require.NotNil(t, result.StructuredContent.Nickname)
+	assert.Equal(t, "alex (please read #rules)", fd.member("9", alexID).Nick)
+
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":   "set_nickname",
+		"user_id":  alexID,
+		"nickname": "",
+	}), &result)
+	assert.Empty(t, fd.member("9", alexID).Nick)
+
+	reply := callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":  "set_nickname",
+		"user_id": alexID,
+	})
+	require.NotNil(t, reply.Error)
+	assert.Contains(t, reply.Error.Message, "nickname is required")
+}
+
+func TestBulkDelete(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: moderatorPermissions})
+	// Messages 1-3 are from 2024, too old for the bulk delete endpoint
+	fd.addMessages("1", 1, 3, func(i int) string { return "buy followers" })
+	for i := 10; i <= 13; i++ {
+		author := &discordgo.User{ID: "42", Username: "tester"}
+		if i == 13 {
+			author = &discordgo.User{ID: alexID, Username: "alex"}
+		}
+		fd.addMessage(&discordgo.Message{
+			ID:        strconv.Itoa(i),
+			ChannelID: "1",
+			Content:   "buy followers",
+			Timestamp: time.Now().Add(time.Duration(i-20) * time.Minute),
+			Author:    author,
+		})
+	}
+
+	var result moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":     "bulk_delete",
+		"channel_id": "1",
+		"user_id":    "42",
+		"reason":     "Spam wave",
+	}), &result)
+	require.NotNil(t, result.StructuredContent.BulkDelete)
+	summary := result.StructuredContent.BulkDelete
+	assert.Equal(t, 6, summary.Matched)
+	assert.Equal(t, 6, summary.Deleted)
+	assert.Equal(t, 3, summary.Bulk)
+	assert.Equal(t, 3, summary.Single)
+	assert.Zero(t, summary.Failed)
+
+	assert.Equal(t, []string{"13"}, fd.messageIDs("1"))
+	assert.Equal(t, 1, fd.bulkDeletes)
+	// One bulk request and three single deletes, each with the reason
+	assert.Equal(t, []string{"Spam wave", "Spam wave", "Spam wave", "Spam wave"}, fd.auditLog())
+}
+
+func TestBulkDeleteRefusesUnboundedDeletes(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: moderatorPermissions})
+	fd.addMessages("1", 1, 5, func(i int) string { return "hello" })
+
+	for name, tc := range map[string]struct {
+		args map[string]interface{}
+		want string
+	}{
+		"guild without criteria": {map[string]interface{}{}, "needs criteria"},
+		"guild time bound only":  {map[string]interface{}{"after": "2024-01-01T00:00:00Z"}, "needs criteria"},
+		"invalid timestamp":      {map[string]interface{}{"channel_id": "1", "before": "yesterday"}, "invalid before timestamp"},
+		"limit too high":         {map[string]interface{}{"channel_id": "1", "limit": 5000}, "limit must be between 1 and 1000"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.args["action"] = "bulk_delete"
+			reply := callToolRPC(t, url, sessionID, "moderate_content", tc.args)
+			require.NotNil(t, reply.Error)
+			assert.Contains(t, reply.Error.Message, tc.want)
+		})
+	}
+	assert.Len(t, fd.messageIDs("1"), 5)
+}
+
+func TestModerationDryRun(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: dryRunPermissions})
+	fd.addMessages("1", 1, 3, func(i int) string { return "buy followers" })
+
+	var kick moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":   "kick_user",
+		"guild_id": "9",
+		"user_id":  "<@" + alexID + ">",
+		"reason":   "Spamming links",
+		"dry_run":  true,
+	}), &kick)
+	assert.True(t, kick.StructuredContent.DryRun)
+	require.NotNil(t, kick.StructuredContent.Target)
+	assert.Equal(t, "alex", kick.StructuredContent.Target.Username)
+
+	var del moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":     "delete_message",
+		"channel_id": "1",
+		"message_id": "2",
+		"dry_run":    true,
+	}), &del)
+	require.NotNil(t, del.StructuredContent.Message)
+	assert.Equal(t, "buy followers", del.StructuredContent.Message.Content)
+
+	var bulk moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":     "bulk_delete",
+		"channel_id": "1",
+		"dry_run":    true,
+	}), &bulk)
+	require.NotNil(t, bulk.StructuredContent.BulkDelete)
+	assert.Equal(t, 3, bulk.StructuredContent.BulkDelete.Matched)
+	assert.Equal(t, 3, bulk.StructuredContent.BulkDelete.Single)
+	assert.Zero(t, bulk.StructuredContent.BulkDelete.Deleted)
+	assert.Len(t, bulk.StructuredContent.BulkDelete.Messages, 3)
+
+	// Nothing reached Discord
+	assert.NotNil(t, fd.member("9", alexID))
+	assert.Equal(t, []string{"1", "2", "3"}, fd.messageIDs("1"))
+	assert.Empty(t, fd.auditLog())
+}
+
+func TestModerationDryRunChecksPermissions(t *testing.T) {
+	_, url, sessionID := newTestGuild(t, testGuild{botPermissions: dryRunPermissions})
+
+	for name, tc := range map[string]struct {
+		args map[string]interface{}
+		want string
+	}{
+		"higher member":      {map[string]interface{}{"action": "kick_user", "guild_id": "9", "user_id": bossID}, "not below the bot's highest role"},
+		"missing permission": {map[string]interface{}{"action": "timeout_user", "user_id": alexID, "duration": "1h"}, "lacks the moderate_members permission"},
+		"not a member":       {map[string]interface{}{"action": "kick_user", "guild_id": "9", "user_id": "100000000000000003"}, "not a member"},
+		"invalid user":       {map[string]interface{}{"action": "ban_user", "guild_id": "9", "user_id": "alex"}, "invalid user_id"},
+		"not banned":         {map[string]interface{}{"action": "unban_user", "user_id": alexID}, "not banned"},
+		"missing message":    {map[string]interface{}{"action": "delete_message", "channel_id": "1", "message_id": "99"}, "failed to get message"},
+		"invalid duration":   {map[string]interface{}{"action": "timeout_user", "user_id": alexID, "duration": "soon"}, "duration"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.args["dry_run"] = true
+			reply := callToolRPC(t, url, sessionID, "moderate_content", tc.args)
+			require.NotNil(t, reply.Error)
+			assert.Contains(t, reply.Error.Message, tc.want)
+		})
+	}
+}
+
+func TestModerationDryRunConfig(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{
+		botPermissions: dryRunPermissions,
+		configure:      func(cfg *config.Config) { cfg.Discord.ModerationDryRun = true },
+	})
+	fd.addBan("9", "100000000000000011", "Raid")
+
+	// The config switch wins over dry_run: false
+	var result moderationResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "moderate_content", map[string]interface{}{
+		"action":  "unban_user",
+		"user_id": "100000000000000011",
+		"dry_run": false,
+	}), &result)
+	assert.True(t, result.StructuredContent.DryRun)
+	assert.Equal(t, []string{"100000000000000011"}, fd.bannedIDs("9"))
+}
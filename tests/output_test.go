@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolsDeclareOutputSchemas(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result mcp.ListToolsResult
+	decodeResult(t, callRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`), &result)
+	require.NotEmpty(t, result.Tools)
+
+	for _, tool := range result.Tools {
+		var schema map[string]interface{}
+		require.NoError(t, json.Unmarshal(tool.OutputSchema, &schema), tool.Name)
+		assert.Equal(t, "object", schema["type"], tool.Name)
+	}
+}
+
+// checkSchema reports where value does not match the JSON schema, covering
+// the keywords the output schemas use. Properties a schema does not declare
+// count as mismatches, so a result cannot drift from its schema unnoticed.
+func checkSchema(path string, schema map[string]interface{}, value interface{}) []string {
+	var problems []string
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		return []string{fmt.Sprintf("%s: %v is not of type %v", path, value, types)}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !slices.Contains(enum, value) {
+		problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+	if schema["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339, value.(string)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v is not a date-time", path, value))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required %s", path, name))
+			}
+		}
+		for name, field := range v {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: undeclared property %s", path, name))
+				continue
+			}
+			problems = append(problems, checkSchema(path+"."+name, property, field)...)
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, checkSchema(fmt.Sprintf("%s[%d]", path, i), items, item)...)
+			}
+		}
+	}
+	return problems
+}
+
+// matchesType reports whether value is of the schema type, or of one of
+// them when the schema lists several.
+func matchesType(types interface{}, value interface{}) bool {
+	if list, ok := types.([]interface{}); ok {
+		for _, typ := range list {
+			if matchesType(typ, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return types == "object"
+	case []interface{}:
+		return types == "array"
+	case string:
+		return types == "string"
+	case bool:
+		return types == "boolean"
+	case float64:
+		return types == "number" || types == "integer" && v == math.Trunc(v)
+	case nil:
+		return types == "null"
+	}
+	return false
+}
+
+func TestCheckSchema(t *testing.T) {
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"count": {"type": "integer"},
+			"at": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": ["string", "null"]}}
+		},
+		"required": ["id"]
+	}`), &schema))
+
+	for doc, want := range map[string]int{
+		`{"id": "1", "count": 2, "at": "2024-01-01T00:00:00Z", "tags": ["a", null]}`: 0,
+		`{"count": 2}`:                        1,
+		`{"id": 1}`:                           1,
+		`{"id": "1", "count": 2.5}`:           1,
+		`{"id": "1", "at": "yesterday"}`:      1,
+		`{"id": "1", "tags": [3]}`:            1,
+		`{"id": "1", "author": "undeclared"}`: 1,
+	} {
+		var value interface{}
+		require.NoError(t, json.Unmarshal([]byte(doc), &value))
+		assert.Len(t, checkSchema("$", schema, value), want, doc)
+	}
+}
+
+// Every declared output schema is checked against real results, including
+// each moderation action's share of the one schema moderate_content declares.
+func TestToolResultsMatchOutputSchemas(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: dryRunPermissions})
+	fd.addMessages("1", 1, 3, func(i int) string { return "buy followers" })
+	fd.addBan("9", "100000000000000011", "Raid")
+
+	var list mcp.ListToolsResult
+	decodeResult(t, callRPC(t, url, sessionID, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`), &list)
+	schemas := map[string]map[string]interface{}{}
+	for _, tool := range list.Tools {
+		var schema map[string]interface{}
+		require.NoError(t, json.Unmarshal(tool.OutputSchema, &schema), tool.Name)
+		schemas[tool.Name] = schema
+	}
+
+	for _, call := range []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"get_messages", map[string]interface{}{"channel_id": "1", "limit": 2}},
+		{"get_channel_info", map[string]interface{}{"channel_id": "1"}},
+		{"moderate_content", map[string]interface{}{"action": "list_bans"}},
+		{"moderate_content", map[string]interface{}{"action": "kick_user", "guild_id": "9", "user_id": alexID, "dry_run": true}},
+		{"moderate_content", map[string]interface{}{"action": "delete_message", "channel_id": "1", "message_id": "2", "dry_run": true}},
+		{"moderate_content", map[string]interface{}{"action": "bulk_delete", "channel_id": "1", "dry_run": true}},
+		{"moderate_content", map[string]interface{}{"action": "bulk_delete", "channel_id": "1"}},
+	} {
+		name := fmt.Sprintf("%s %v", call.tool, call.args["action"])
+		var result struct {
+			StructuredContent interface{} `json:"structuredContent"`
+		}
+		decodeResult(t, callToolRPC(t, url, sessionID, call.tool, call.args), &result)
+		require.NotNil(t, result.StructuredContent, name)
+		assert.Empty(t, checkSchema("$", schemas[call.tool], result.StructuredContent), name)
+	}
+}
+
+func TestGetMessagesStructuredContent(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 1, 5, func(i int) string { return fmt.Sprintf("message %d", i) })
+
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result struct {
+		Content           []mcp.ToolContent     `json:"content"`
+		StructuredContent mcp.MessageListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_messages","arguments":{"channel_id":"1","limit":2}}}`),
+		&result)
+
+	structured := result.StructuredContent
+	assert.Equal(t, "1", structured.ChannelID)
+	assert.Equal(t, 2, structured.Count)
+	require.Len(t, structured.Messages, 2)
+	assert.Equal(t, "5", structured.Messages[0].ID)
+	assert.Equal(t, "42", structured.Messages[0].AuthorID)
+	assert.Equal(t, "4", structured.NextBefore)
+
+	require.Len(t, result.Content, 1)
+	assert.Contains(t, result.Content[0].Text, "tester: message 5")
+}
+
+func TestOlderProtocolOmitsStructuredOutput(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addMessages("1", 1, 5, func(i int) string { return fmt.Sprintf("message %d", i) })
+
+	cfg := newTestConfig()
+	cfg.MCP.ProtocolVersion = "2025-03-26"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	var tools mcp.ListToolsResult
+	decodeResult(t, callRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`), &tools)
+	require.NotEmpty(t, tools.Tools)
+	for _, tool := range tools.Tools {
+		assert.Empty(t, tool.OutputSchema, tool.Name)
+	}
+
+	var result map[string]interface{}
+	decodeResult(t, callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_messages","arguments":{"channel_id":"1","limit":2}}}`),
+		&result)
+	assert.Contains(t, result, "content")
+	assert.NotContains(t, result, "structuredContent")
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tools/list hides tools without a permission mapping, so a caller with
+// every permission must be shown every tool the server offers.
+func TestEveryToolHasPermission(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Index.Enabled = true
+	cfg.Index.Path = filepath.Join(t.TempDir(), "index.db")
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server, err := mcp.NewServer(cfg, logger)
+	require.NoError(t, err)
+
+	var offered []string
+	for _, tool := range server.Tools() {
+		offered = append(offered, tool.Name)
+	}
+	require.Contains(t, offered, "search_index")
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+	reply := callRPC(t, ts.URL, initializeSession(t, ts.URL), `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	var result mcp.ListToolsResult
+	decodeResult(t, reply, &result)
+	var listed []string
+	for _, tool := range result.Tools {
+		listed = append(listed, tool.Name)
+	}
+	assert.ElementsMatch(t, offered, listed)
+}
+
+func TestUnmappedToolIsRefused(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	reply := callToolRPC(t, ts.URL, initializeSession(t, ts.URL), "drop_guild", map[string]interface{}{})
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, mcp.MethodNotFound, reply.Error.Code)
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/config"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeResult(t *testing.T, reply mcp.JSONRPCResponse, v interface{}) {
+	require.Nil(t, reply.Error)
+	data, err := json.Marshal(reply.Result)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, v))
+}
+
+func TestPromptsList(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MCP.Prompts = []config.PromptConfig{
+		{Name: "weekly_digest", Description: "Weekly digest", Template: "Digest"},
+	}
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	var result mcp.ListPromptsResult
+	decodeResult(t, callRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`), &result)
+
+	var names []string
+	for _, prompt := range result.Prompts {
+		names = append(names, prompt.Name)
+	}
+	assert.ElementsMatch(t, []string{"summarize_channel", "triage_reports", "draft_announcement", "weekly_digest"}, names)
+}
+
+func TestPromptsGet(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result mcp.GetPromptResult
+	decodeResult(t, callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"draft_announcement","arguments":{"topic":"the v2 launch","audience":"moderators"}}}`),
+		&result)
+
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, "text", result.Messages[0].Content.Type)
+	assert.Contains(t, result.Messages[0].Content.Text, "about the v2 launch for moderators.")
+}
+
+func TestPromptsGetMissingArgument(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	reply := callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"summarize_channel","arguments":{}}}`)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, mcp.InvalidParams, reply.Error.Code)
+
+	reply = callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"no_such_prompt"}}`)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, mcp.InvalidParams, reply.Error.Code)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/ReesavGupta/discord-mcp-server/internal/redact"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactorValue(t *testing.T) {
+	r := redact.New(redact.Config{MaxContentLength: 10, ScrubPatterns: true})
+
+	assert.Equal(t, "fp:"+redact.Fingerprint("sk-live-123"), r.Value("api_key", "sk-live-123"))
+	assert.Equal(t, "[REDACTED]", r.Value("email", "alex@example.com"))
+	assert.Equal(t, "0123456789... [26 chars]", r.Value("content", "0123456789abcdefghijklmnop"))
+	assert.Equal(t, "ping [email] from [ip]", r.Value("reason", "ping alex@example.com from 10.0.0.1"))
+	assert.Equal(t, 42, r.Value("limit", 42))
+	assert.Equal(t, "[ip]", r.Value("remote", net.ParseIP("10.0.0.1")))
+	assert.Equal(t, time.Minute, r.Value("duration", time.Minute))
+
+	nested := r.Value("arguments", map[string]interface{}{
+		"channel_id": "123",
+		"content":    "a very long message body",
+		"embeds":     []interface{}{map[string]interface{}{"token": "abc"}},
+	}).(map[string]interface{})
+	assert.Equal(t, "123", nested["channel_id"])
+	assert.Equal(t, "a very lon... [24 chars]", nested["content"])
+	assert.Equal(t, "fp:"+redact.Fingerprint("abc"), nested["embeds"].([]interface{})[0].(map[string]interface{})["token"])
+}
+
+func TestRedactorContentModes(t *testing.T) {
+	assert.Equal(t, "[5 chars]", redact.New(redact.Config{ContentMode: redact.ContentMask}).Content("hello"))
+	assert.Equal(t, "hello", redact.New(redact.Config{ContentMode: redact.ContentFull}).Content("hello"))
+	assert.Equal(t, "sha256:"+redact.Fingerprint("hello"), redact.New(redact.Config{ContentMode: redact.ContentHash}).Content("hello"))
+}
+
+func TestRedactHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(redact.NewHook(redact.New(redact.Config{ContentMode: redact.ContentMask})))
+
+	logger.WithFields(logrus.Fields{
+		"channel_id": "123",
+		"content":    "secret plans",
+	}).WithError(errors.New("failed")).Info("Sending message")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "[12 chars]", line["content"])
+	assert.Equal(t, "123", line["channel_id"])
+	assert.Equal(t, "failed", line["error"])
+}
+
+func TestAuditLogRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger := logrus.New()
+	logger.SetOutput(&bytes.Buffer{})
+
+	am, err := auth.NewAuthManager("", []string{"sk-live-123"}, logger, true, path)
+	require.NoError(t, err)
+	am.Auditor().SetRedactor(redact.New(redact.Config{ContentMode: redact.ContentMask}))
+
+	am.ValidateAPIKey("sk-live-123")
+	am.Auditor().LogToolCall("send_message", "u1", map[string]interface{}{"content": "hello world"}, nil, nil, 0)
+	require.NoError(t, am.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "sk-live-123"))
+	assert.False(t, strings.Contains(string(data), "hello world"))
+	assert.Contains(t, string(data), auth.KeyFingerprint("sk-live-123"))
+	assert.Contains(t, string(data), "[11 chars]")
+}
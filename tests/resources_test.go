@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTemplatesList(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	reply := callRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":1,"method":"resources/templates/list"}`)
+	require.Nil(t, reply.Error)
+
+	data, err := json.Marshal(reply.Result)
+	require.NoError(t, err)
+	var result mcp.ListResourceTemplatesResult
+	require.NoError(t, json.Unmarshal(data, &result))
+
+	var templates []string
+	for _, tmpl := range result.ResourceTemplates {
+		templates = append(templates, tmpl.URITemplate)
+	}
+	assert.ElementsMatch(t, []string{
+		"discord://guild/{guild_id}",
+		"discord://channel/{channel_id}",
+		"discord://channel/{channel_id}/messages",
+	}, templates)
+}
+
+func TestResourcesReadUnknownURI(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	for _, uri := range []string{"https://example.com", "discord://user/1", "discord://channel/1/pins"} {
+		reply := callRPC(t, ts.URL, sessionID,
+			`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"`+uri+`"}}`)
+		require.NotNil(t, reply.Error, uri)
+		assert.Equal(t, mcp.ResourceNotFound, reply.Error.Code, uri)
+	}
+}
+
+func TestResourcesReadMissingChannel(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	for _, uri := range []string{"discord://channel/2", "discord://guild/8"} {
+		reply := callRPC(t, ts.URL, sessionID,
+			`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"`+uri+`"}}`)
+		require.NotNil(t, reply.Error, uri)
+		// The MCP specification fixes resource not found at -32002
+		assert.Equal(t, -32002, reply.Error.Code, uri)
+	}
+
+	reply := callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"discord://channel/1"}}`)
+	assert.Nil(t, reply.Error)
+}
+
+func TestResourcesSubscribe(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	reply := callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"discord://channel/123/messages?limit=10"}}`)
+	assert.Nil(t, reply.Error)
+
+	reply = callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"discord://guild/123"}}`)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, mcp.InvalidParams, reply.Error.Code)
+
+	reply = callRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/unsubscribe","params":{"uri":"discord://channel/123/messages"}}`)
+	assert.Nil(t, reply.Error)
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/config"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rolePermissions let the bot manage roles and messages.
+const rolePermissions = discordgo.PermissionManageRoles | discordgo.PermissionManageMessages
+
+type roleResult struct {
+	StructuredContent mcp.DiscordRole `json:"structuredContent"`
+}
+
+func TestListRoles(t *testing.T) {
+	_, url, sessionID := newTestGuild(t, testGuild{botPermissions: rolePermissions})
+
+	var result struct {
+		StructuredContent mcp.RoleListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, url, sessionID, "list_roles", map[string]interface{}{}), &result)
+
+	var ids []string
+	for _, role := range result.StructuredContent.Roles {
+		ids = append(ids, role.ID)
+	}
+	assert.Equal(t, []string{"700", "600", "550", "510", "500", "501", "9"}, ids)
+	assert.Equal(t, []string{"manage_messages", "manage_roles"}, result.StructuredContent.Roles[1].Permissions)
+	assert.True(t, result.StructuredContent.Roles[5].Managed)
+}
+
+func TestCreateAndEditRole(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: rolePermissions})
+
+	var created roleResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "create_role", map[string]interface{}{
+		"name":        "Incident",
+		"color":       "#ff0000",
+		"permissions": []interface{}{"send_messages", "manage_messages"},
+		"hoist":       true,
+	}), &created)
+	assert.Equal(t, "Incident", created.StructuredContent.Name)
+	assert.Equal(t, "#ff0000", created.StructuredContent.Color)
+	assert.Equal(t, []string{"manage_messages", "send_messages"}, created.StructuredContent.Permissions)
+	assert.True(t, created.StructuredContent.Hoist)
+
+	var edited roleResult
+	decodeResult(t, callToolRPC(t, url, sessionID, "edit_role", map[string]interface{}{
+		"role":        "oncall",
+		"mentionable": true,
+		"reason":      "Page the on-call engineer",
+	}), &edited)
+	assert.Equal(t, "500", edited.StructuredContent.ID)
+	assert.True(t, fd.role("9", "500").Mentionable)
+	assert.Equal(t, []string{"Page the on-call engineer"}, fd.auditLog())
+}
+
+func TestRoleHierarchyChecks(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: rolePermissions})
+
+	for name, tc := range map[string]struct {
+		tool string
+		args map[string]interface{}
+		want string
+	}{
+		"grant missing permission": {"create_role", map[string]interface{}{"name": "x", "permissions": []interface{}{"ban_members"}}, "cannot grant permissions it does not have: ban_members"},
+		"unknown permission":       {"create_role", map[string]interface{}{"name": "x", "permissions": []interface{}{"fly"}}, "unknown permission"},
+		"edit higher role":         {"edit_role", map[string]interface{}{"role": "Admin", "name": "Owner"}, "not below the bot's highest role"},
+		"edit own role":            {"edit_role", map[string]interface{}{"role": "600", "hoist": true}, "not below the bot's highest role"},
+		"edit nothing":             {"edit_role", map[string]interface{}{"role": "Oncall"}, "nothing to change"},
+		"delete higher role":       {"delete_role", map[string]interface{}{"role": "700"}, "not below the bot's highest role"},
+		"delete everyone":          {"delete_role", map[string]interface{}{"role": "9"}, "@everyone"},
+		"assign higher role":       {"add_member_role", map[string]interface{}{"user_id": alexID, "role": "Admin"}, "not below the bot's highest role"},
+		"assign managed role":      {"add_member_role", map[string]interface{}{"user_id": alexID, "role": "Integration"}, "managed by an integration"},
+		"unknown role":             {"remove_member_role", map[string]interface{}{"user_id": alexID, "role": "Nope"}, "has no role"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reply := callToolRPC(t, url, sessionID, tc.tool, tc.args)
+			require.NotNil(t, reply.Error)
+			assert.Contains(t, reply.Error.Message, tc.want)
+		})
+	}
+
+	// Refused changes never reach Discord
+	assert.Equal(t, "Admin", fd.role("9", "700").Name)
+	assert.NotNil(t, fd.role("9", "9"))
+	assert.Empty(t, fd.memberRoles("9", alexID))
+	assert.Empty(t, fd.auditLog())
+}
+
+func TestMemberRolesAndDelete(t *testing.T) {
+	fd, url, sessionID := newTestGuild(t, testGuild{botPermissions: rolePermissions})
+
+	var added struct {
+		StructuredContent mcp.RoleActionResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, url, sessionID, "add_member_role", map[string]interface{}{
+		"user_id": "<@" + alexID + ">",
+		"role":    "Oncall",
+		"reason":  "On call this week",
+	}), &added)
+	assert.Equal(t, mcp.RoleActionResult{
+		Action:  "add_member_role",
+		GuildID: "9",
+		RoleID:  "500",
+		UserID:  alexID,
+		Reason:  "On call this week",
+	}, added.StructuredContent)
+	assert.Equal(t, []string{"500"}, fd.memberRoles("9", alexID))
+
+	decodeResult(t, callToolRPC(t, url, sessionID, "remove_member_role", map[string]interface{}{
+		"user_id": alexID,
+		"role":    "500",
+	}), &added)
+	assert.Empty(t, fd.memberRoles("9", alexID))
+
+	decodeResult(t, callToolRPC(t, url, sessionID, "delete_role", map[string]interface{}{"role": "Oncall"}), &added)
+	assert.Equal(t, "delete_role", added.StructuredContent.Action)
+	assert.Nil(t, fd.role("9", "500"))
+}
+
+// With allowed_roles set, a JWT caller acts as their Discord member, so a
+// change must also sit below their own highest role and grant nothing
+// they lack, unless they own the guild.
+func TestRoleChangesRespectCallerHierarchy(t *testing.T) {
+	fd, url, _ := newTestGuild(t, testGuild{
+		botPermissions: discordgo.PermissionManageRoles | discordgo.PermissionBanMembers,
+		alexRoles:      []string{"510"},
+		configure: func(cfg *config.Config) {
+			cfg.Discord.AllowedRoles = []string{"Moderator"}
+			cfg.Auth.Required = true
+			cfg.Auth.JWTSecret = testJWTSecret
+		},
+	})
+
+	token, err := newTestAuthManager(t).GenerateToken(alexID, []string{"roles:*"}, "bot-1")
+	require.NoError(t, err)
+	sessionID := initializeWithHeader(t, url, "Authorization", "Bearer "+token)
+
+	call := func(name string, args map[string]interface{}) mcp.JSONRPCResponse {
+		params, err := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+string(params)+`}`))
+		require.NoError(t, err)
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return reply
+	}
+
+	// The bot could do both; alex could not
+	reply := call("add_member_role", map[string]interface{}{"user_id": alexID, "role": "Lead"})
+	require.NotNil(t, reply.Error)
+	assert.Contains(t, reply.Error.Message, "not below the caller's highest role")
+	reply = call("create_role", map[string]interface{}{"name": "x", "permissions": []interface{}{"ban_members"}})
+	require.NotNil(t, reply.Error)
+	assert.Contains(t, reply.Error.Message, "the caller cannot grant permissions it does not have: ban_members")
+	assert.Equal(t, []string{"510"}, fd.memberRoles("9", alexID))
+
+	reply = call("add_member_role", map[string]interface{}{"user_id": alexID, "role": "Oncall"})
+	require.Nil(t, reply.Error)
+	assert.Equal(t, []string{"510", "500"}, fd.memberRoles("9", alexID))
+
+	// The owner is above every role
+	fd.setOwner("9", alexID)
+	reply = call("add_member_role", map[string]interface{}{"user_id": alexID, "role": "Lead"})
+	require.Nil(t, reply.Error)
+	assert.Equal(t, []string{"510", "500", "550"}, fd.memberRoles("9", alexID))
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillExceedsMaxHistory(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addMessages("1", 1, 2500, func(i int) string { return "message " + strconv.Itoa(i) })
+
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	cfg.Discord.MaxHistory = 1000
+	cfg.Index.Enabled = true
+	cfg.Index.Path = filepath.Join(t.TempDir(), "index.db")
+	cfg.Index.Channels = []string{"1"}
+	cfg.Index.Backfill = 2200
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	server, err := mcp.NewServer(cfg, logger)
+	require.NoError(t, err)
+	server.BackfillIndex(context.Background())
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(ts.Close)
+	sessionID := initializeSession(t, ts.URL)
+
+	// The newest 2200 messages are 301 to 2500
+	for query, want := range map[string]int{"301": 1, "2500": 1, "300": 0} {
+		var result struct {
+			StructuredContent mcp.IndexSearchResult `json:"structuredContent"`
+		}
+		decodeResult(t, callToolRPC(t, ts.URL, sessionID, "search_index", map[string]interface{}{"query": query}), &result)
+		assert.Equal(t, want, result.StructuredContent.Count, query)
+	}
+}
+
+func TestSearchIndexIsHeldToTheCallersGuild(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addRole("9", "510", "Moderator")
+	fd.addMember("9", alexID, "alex", "", "510")
+	fd.addMember("8", alexID, "alex", "")
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("8", "2", discordgo.ChannelTypeGuildText, false)
+	fd.addMessages("1", 11, 1, func(i int) string { return "incident report" })
+	fd.addMessages("2", 12, 1, func(i int) string { return "incident report" })
+
+	cfg := newTestConfig()
+	cfg.Discord.GuildID = "9"
+	cfg.Discord.AllowedRoles = []string{"Moderator"}
+	cfg.Auth.Required = true
+	cfg.Auth.JWTSecret = testJWTSecret
+	cfg.Index.Enabled = true
+	cfg.Index.Path = filepath.Join(t.TempDir(), "index.db")
+	cfg.Index.Channels = []string{"1", "2"}
+	cfg.Index.Backfill = 10
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	server, err := mcp.NewServer(cfg, logger)
+	require.NoError(t, err)
+	server.BackfillIndex(context.Background())
+	ts := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(ts.Close)
+
+	token, err := newTestAuthManager(t).GenerateToken(alexID, []string{"messages:read"}, "bot-1")
+	require.NoError(t, err)
+	sessionID := initializeWithHeader(t, ts.URL, "Authorization", "Bearer "+token)
+	search := func(args map[string]interface{}) mcp.JSONRPCResponse {
+		params, err := json.Marshal(map[string]interface{}{"name": "search_index", "arguments": args})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+string(params)+`}`))
+		require.NoError(t, err)
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return reply
+	}
+
+	// With no guild named, only the configured guild is searched
+	var result struct {
+		StructuredContent mcp.IndexSearchResult `json:"structuredContent"`
+	}
+	decodeResult(t, search(map[string]interface{}{"query": "incident"}), &result)
+	require.Len(t, result.StructuredContent.Results, 1)
+	assert.Equal(t, "11", result.StructuredContent.Results[0].Message.ID)
+
+	// alex is no moderator in guild 8, however it is named
+	for _, args := range []map[string]interface{}{
+		{"query": "incident", "guild_id": "8"},
+		{"query": "incident", "channel_id": "2"},
+	} {
+		reply := search(args)
+		require.NotNil(t, reply.Error)
+		assert.Equal(t, mcp.Forbidden, reply.Error.Code)
+	}
+}
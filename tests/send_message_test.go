@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/auth"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callToolRPC calls a tool over an initialized HTTP session.
+func callToolRPC(t *testing.T, url, sessionID, name string, args map[string]interface{}) mcp.JSONRPCResponse {
+	params, err := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	require.NoError(t, err)
+	return callRPC(t, url, sessionID, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+string(params)+`}`)
+}
+
+func TestSendMessageRich(t *testing.T) {
+	fd := newFakeDiscord(t)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var result mcp.CallToolResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "send_message", map[string]interface{}{
+		"channel_id": "1",
+		"content":    "Release notes @everyone",
+		"reply_to":   "77",
+		"embeds": []interface{}{
+			map[string]interface{}{
+				"title":  "v2.0",
+				"color":  "#ff8800",
+				"fields": []interface{}{map[string]interface{}{"name": "Changes", "value": "Lots", "inline": true}},
+			},
+		},
+		"attachments": []interface{}{
+			map[string]interface{}{"filename": "notes.txt", "content_base64": base64.StdEncoding.EncodeToString([]byte("hello"))},
+		},
+	}), &result)
+
+	sent := fd.sentMessages()
+	require.Len(t, sent, 1)
+	payload := sent[0].Payload
+	assert.Equal(t, "Release notes @everyone", payload.Content)
+	require.NotNil(t, payload.Reference)
+	assert.Equal(t, "77", payload.Reference.MessageID)
+	require.Len(t, payload.Embeds, 1)
+	assert.Equal(t, 0xff8800, payload.Embeds[0].Color)
+	assert.Equal(t, "Changes", payload.Embeds[0].Fields[0].Name)
+	assert.Equal(t, []byte("hello"), sent[0].Files["notes.txt"])
+
+	// @everyone is not pinged unless asked for
+	require.NotNil(t, payload.AllowedMentions)
+	assert.ElementsMatch(t, []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers, discordgo.AllowedMentionTypeRoles}, payload.AllowedMentions.Parse)
+}
+
+func TestSendMessageAttachmentDirectory(t *testing.T) {
+	fd := newFakeDiscord(t)
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "report.csv"), []byte("a,b"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(root, "secret.txt"), filepath.Join(dir, "link.txt")))
+
+	cfg := newTestConfig()
+	cfg.Discord.AttachmentDir = dir
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	attach := func(path string) mcp.JSONRPCResponse {
+		return callToolRPC(t, ts.URL, sessionID, "send_message", map[string]interface{}{
+			"channel_id":  "1",
+			"attachments": []interface{}{map[string]interface{}{"path": path}},
+		})
+	}
+
+	require.Nil(t, attach("report.csv").Error)
+	sent := fd.sentMessages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, []byte("a,b"), sent[0].Files["report.csv"])
+
+	// Missing files outside are refused the same way, so the error does not
+	// reveal what exists there
+	for _, path := range []string{"../secret.txt", filepath.Join(root, "secret.txt"), "link.txt", "../missing.txt", filepath.Join(root, "missing.txt")} {
+		reply := attach(path)
+		require.NotNil(t, reply.Error, path)
+		assert.Contains(t, reply.Error.Message, "outside the attachment directory", path)
+	}
+	assert.Len(t, fd.sentMessages(), 1)
+}
+
+func TestSendMessageRejectsInvalidArguments(t *testing.T) {
+	fd := newFakeDiscord(t)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	for name, args := range map[string]map[string]interface{}{
+		"empty message": {"channel_id": "1"},
+		"conflicting mentions": {"channel_id": "1", "content": "hi", "allowed_mentions": map[string]interface{}{
+			"parse": []interface{}{"users"}, "users": []interface{}{"42"},
+		}},
+		"unknown mention type": {"channel_id": "1", "content": "hi", "allowed_mentions": map[string]interface{}{
+			"parse": []interface{}{"here"},
+		}},
+		"local files disabled": {"channel_id": "1", "attachments": []interface{}{map[string]interface{}{"path": "a.txt"}}},
+		"bad color":            {"channel_id": "1", "embeds": []interface{}{map[string]interface{}{"title": "x", "color": "blue"}}},
+	} {
+		reply := callToolRPC(t, ts.URL, sessionID, "send_message", args)
+		assert.NotNil(t, reply.Error, name)
+	}
+	assert.Empty(t, fd.sentMessages())
+}
+
+// An attachment whose base64 alone is larger than an audit record may be
+// must not stop the audit log being reopened or verified.
+func TestLargeAttachmentKeepsAuditLogReadable(t *testing.T) {
+	fd := newFakeDiscord(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg := newTestConfig()
+	cfg.Auth.EnableAudit = true
+	cfg.Auth.AuditLogPath = path
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	server, err := mcp.NewServer(cfg, logger)
+	require.NoError(t, err)
+
+	file := bytes.Repeat([]byte("0123456789abcdef"), 20<<20/16)
+	sum := sha256.Sum256(file)
+	request, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: map[string]interface{}{
+			"name": "send_message",
+			"arguments": map[string]interface{}{
+				"channel_id":  "1",
+				"attachments": []interface{}{map[string]interface{}{"filename": "dump.bin", "content_base64": base64.StdEncoding.EncodeToString(file)}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, server.ServeStdio(bytes.NewReader(request), &out))
+	assert.NotContains(t, out.String(), `"error"`)
+	require.Len(t, fd.sentMessages(), 1)
+
+	_, err = auth.NewAuthManager("", nil, logger, true, path)
+	require.NoError(t, err)
+	count, _, err := auth.VerifyAuditLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Less(t, len(data), 4096)
+	assert.Contains(t, string(data), `"filename":"dump.bin"`)
+	assert.Contains(t, string(data), hex.EncodeToString(sum[:]))
+	assert.Contains(t, string(data), `"size":20971520`)
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/discord"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openStream starts a session, subscribes it to uris and opens its SSE
+// stream, returning the URIs of the resource updates it receives.
+func openStream(t *testing.T, url string, uris ...string) <-chan string {
+	sessionID := initializeSession(t, url)
+	for _, uri := range uris {
+		reply := callRPC(t, url, sessionID, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"`+uri+`"}}`)
+		require.Nil(t, reply.Error)
+	}
+
+	req := newRPCRequest(t, http.MethodGet, url, sessionID, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	updates := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var msg struct {
+				Method string                    `json:"method"`
+				Params mcp.ResourceUpdatedParams `json:"params"`
+			}
+			if json.Unmarshal([]byte(data), &msg) == nil && msg.Method == "notifications/resources/updated" {
+				updates <- msg.Params.URI
+			}
+		}
+	}()
+	return updates
+}
+
+func receive(t *testing.T, updates <-chan string) string {
+	select {
+	case uri := <-updates:
+		return uri
+	case <-time.After(time.Second):
+		t.Fatal("no resource update received")
+		return ""
+	}
+}
+
+func TestMessageEventsNotifySubscribers(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server, err := mcp.NewServer(newTestConfig(), logger)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(ts.Close)
+
+	subscriber := openStream(t, ts.URL, "discord://channel/123/messages?limit=5", "discord://channel/456/messages")
+	other := openStream(t, ts.URL, "discord://channel/456/messages")
+
+	for _, event := range []discord.MessageEvent{
+		{Type: discord.MessageCreated, ChannelID: "123", MessageIDs: []string{"1"}},
+		{Type: discord.MessageUpdated, ChannelID: "123", MessageIDs: []string{"1"}},
+		{Type: discord.MessageDeleted, ChannelID: "123", MessageIDs: []string{"1"}},
+		{Type: discord.MessageCreated, ChannelID: "999", MessageIDs: []string{"2"}},
+		{Type: discord.MessageCreated, ChannelID: "456", MessageIDs: []string{"3"}},
+	} {
+		server.HandleMessageEvent(event)
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "discord://channel/123/messages", receive(t, subscriber))
+	}
+	// Channel 999 notified no one, so 456 comes next on both streams
+	assert.Equal(t, "discord://channel/456/messages", receive(t, subscriber))
+	assert.Equal(t, "discord://channel/456/messages", receive(t, other))
+}
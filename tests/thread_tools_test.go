@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type threadResult struct {
+	StructuredContent mcp.DiscordThread `json:"structuredContent"`
+}
+
+type threadListResult struct {
+	StructuredContent mcp.ThreadListResult `json:"structuredContent"`
+}
+
+func threadIDs(list mcp.ThreadListResult) []string {
+	var ids []string
+	for _, thread := range list.Threads {
+		ids = append(ids, thread.ID)
+	}
+	return ids
+}
+
+func TestCreateThreads(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addMessages("1", 10, 1, func(i int) string { return "Help, my build is broken" })
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var fromMessage threadResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "create_thread", map[string]interface{}{
+		"channel_id": "1", "message_id": "10", "name": "Broken build",
+	}), &fromMessage)
+	assert.Equal(t, "10", fromMessage.StructuredContent.ID)
+	assert.Equal(t, "1", fromMessage.StructuredContent.ParentID)
+
+	var standalone threadResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "create_thread", map[string]interface{}{
+		"channel_id": "1", "name": "Mods only", "private": true, "auto_archive_minutes": 1440,
+	}), &standalone)
+	assert.True(t, standalone.StructuredContent.Private)
+	assert.Equal(t, 1440, standalone.StructuredContent.AutoArchiveMinutes)
+
+	reply := callToolRPC(t, ts.URL, sessionID, "create_thread", map[string]interface{}{
+		"channel_id": "1", "message_id": "10", "name": "x", "private": true,
+	})
+	assert.NotNil(t, reply.Error)
+}
+
+func TestListThreads(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addChannel("9", "2", discordgo.ChannelTypeGuildText, false)
+	fd.addThread("1", "11", false, time.Time{})
+	fd.addThread("2", "21", false, time.Time{})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fd.addThread("1", "12", false, base.Add(1*time.Hour))
+	fd.addThread("1", "13", false, base.Add(2*time.Hour))
+	fd.addThread("1", "14", false, base.Add(3*time.Hour))
+	fd.addThread("1", "15", true, base.Add(4*time.Hour))
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	var active threadListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_threads", map[string]interface{}{"channel_id": "1"}), &active)
+	assert.Equal(t, []string{"11"}, threadIDs(active.StructuredContent))
+
+	var archived threadListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_threads", map[string]interface{}{
+		"channel_id": "1", "archived": true, "limit": 2,
+	}), &archived)
+	assert.Equal(t, []string{"14", "13"}, threadIDs(archived.StructuredContent))
+	require.NotEmpty(t, archived.StructuredContent.NextBefore)
+
+	var older threadListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_threads", map[string]interface{}{
+		"channel_id": "1", "archived": true, "limit": 2, "before": archived.StructuredContent.NextBefore,
+	}), &older)
+	assert.Equal(t, []string{"12"}, threadIDs(older.StructuredContent))
+	assert.Empty(t, older.StructuredContent.NextBefore)
+
+	var private threadListResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "list_threads", map[string]interface{}{
+		"channel_id": "1", "archived": true, "private": true,
+	}), &private)
+	assert.Equal(t, []string{"15"}, threadIDs(private.StructuredContent))
+}
+
+func TestJoinLeaveAndArchiveThread(t *testing.T) {
+	fd := newFakeDiscord(t)
+	fd.addChannel("9", "1", discordgo.ChannelTypeGuildText, false)
+	fd.addThread("1", "11", false, time.Time{})
+	fd.addMessages("11", 100, 3, func(i int) string { return "reply" })
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	require.Nil(t, callToolRPC(t, ts.URL, sessionID, "join_thread", map[string]interface{}{"thread_id": "11"}).Error)
+	assert.True(t, fd.isThreadMember("11", fakeBotID))
+	require.Nil(t, callToolRPC(t, ts.URL, sessionID, "leave_thread", map[string]interface{}{"thread_id": "11"}).Error)
+	assert.False(t, fd.isThreadMember("11", fakeBotID))
+
+	// Thread messages are read like any channel's
+	var messages struct {
+		StructuredContent mcp.MessageListResult `json:"structuredContent"`
+	}
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "get_messages", map[string]interface{}{"channel_id": "11"}), &messages)
+	assert.Equal(t, 3, messages.StructuredContent.Count)
+
+	var archived threadResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "archive_thread", map[string]interface{}{
+		"thread_id": "11", "locked": true,
+	}), &archived)
+	assert.True(t, archived.StructuredContent.Archived)
+	assert.True(t, archived.StructuredContent.Locked)
+
+	var reopened threadResult
+	decodeResult(t, callToolRPC(t, ts.URL, sessionID, "archive_thread", map[string]interface{}{
+		"thread_id": "11", "archived": false,
+	}), &reopened)
+	assert.False(t, reopened.StructuredContent.Archived)
+	assert.True(t, reopened.StructuredContent.Locked)
+}
@@ -0,0 +1,356 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReesavGupta/discord-mcp-server/internal/config"
+	"github.com/ReesavGupta/discord-mcp-server/internal/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAPIKey is accepted by servers built from newTestConfig and grants
+// every permission. The request helpers send it.
+const testAPIKey = "test-key"
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.Name = "discord-mcp-server"
+	cfg.Server.Version = "1.0.0"
+	cfg.Discord.BotToken = "test-token"
+	cfg.Auth.APIKeys = []string{testAPIKey}
+	cfg.MCP.ProtocolVersion = "2025-06-18"
+	cfg.MCP.Transport = "http"
+	cfg.MCP.HTTP.Path = "/mcp"
+	return cfg
+}
+
+func newTestHTTPServer(t *testing.T, cfg *config.Config) *httptest.Server {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	server, err := mcp.NewServer(cfg, logger)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newRPCRequest builds a request to the MCP endpoint at url, authenticated
+// with testAPIKey and on the given session, if any.
+func newRPCRequest(t *testing.T, method, url, sessionID string, body io.Reader) *http.Request {
+	req, err := http.NewRequest(method, url+"/mcp", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("X-API-Key", testAPIKey)
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	return req
+}
+
+func postRPC(t *testing.T, url, sessionID, body string) *http.Response {
+	req := newRPCRequest(t, http.MethodPost, url, sessionID, strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func initializeSession(t *testing.T, url string) string {
+	resp := postRPC(t, url, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	require.NotEmpty(t, sessionID)
+	return sessionID
+}
+
+func TestHTTPTransportSessionLifecycle(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+
+	sessionID := initializeSession(t, ts.URL)
+
+	resp := postRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp = postRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var listResp struct {
+		ID     float64             `json:"id"`
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listResp))
+	assert.Equal(t, float64(2), listResp.ID)
+	assert.NotEmpty(t, listResp.Result.Tools)
+
+	req := newRPCRequest(t, http.MethodDelete, ts.URL, sessionID, nil)
+	delResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	delResp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, delResp.StatusCode)
+
+	resp = postRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHTTPTransportRequiresSession(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+
+	resp := postRPC(t, ts.URL, "", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestInitializeNegotiatesProtocolVersion(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MCP.ProtocolVersion = "2025-03-26"
+	ts := newTestHTTPServer(t, cfg)
+
+	for requested, want := range map[string]string{
+		`"2025-06-18"`: "2025-06-18",
+		`"2024-11-05"`: "2024-11-05",
+		`"1999-01-01"`: "2025-03-26",
+		`null`:         "2025-03-26",
+	} {
+		resp := postRPC(t, ts.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":`+requested+`}}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var reply struct {
+			Result mcp.InitializeResult `json:"result"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		assert.Equal(t, want, reply.Result.ProtocolVersion, requested)
+	}
+}
+
+func TestHTTPTransportBatch(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MCP.ProtocolVersion = "2025-03-26"
+	ts := newTestHTTPServer(t, cfg)
+	sessionID := initializeSession(t, ts.URL)
+
+	resp := postRPC(t, ts.URL, sessionID, `[
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		{"jsonrpc":"2.0","id":2,"method":"prompts/list"}
+	]`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var replies []mcp.JSONRPCResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&replies))
+	assert.Len(t, replies, 2)
+}
+
+func TestHTTPTransportRejectsBatchIn20250618(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	resp := postRPC(t, ts.URL, sessionID, `[{"jsonrpc":"2.0","id":1,"method":"tools/list"}]`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var reply mcp.JSONRPCResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, mcp.InvalidRequest, reply.Error.Code)
+}
+
+func TestHTTPTransportRejectsForeignOrigin(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MCP.HTTP.AllowedOrigins = []string{"http://localhost"}
+	ts := newTestHTTPServer(t, cfg)
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	require.NoError(t, err)
+	req.Header.Set("Origin", "http://evil.example")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestHTTPTransportDefaultOrigins(t *testing.T) {
+	ts := newTestHTTPServer(t, newTestConfig())
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	for origin, want := range map[string]int{
+		"http://" + host:        http.StatusOK,
+		"http://localhost:3000": http.StatusOK,
+		"http://evil.example":   http.StatusForbidden,
+		"http://evil.example:" + strings.Split(host, ":")[1]: http.StatusForbidden,
+	} {
+		req := newRPCRequest(t, http.MethodPost, ts.URL, "",
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+		req.Header.Set("Origin", origin)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, want, resp.StatusCode, origin)
+	}
+}
+
+// callRPC posts body on an existing session and decodes the single reply.
+func callRPC(t *testing.T, url, sessionID, body string) mcp.JSONRPCResponse {
+	resp := postRPC(t, url, sessionID, body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var reply mcp.JSONRPCResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	return reply
+}
+
+func TestHTTPTransportCancelledNotification(t *testing.T) {
+	fd := newFakeDiscord(t)
+	started, aborted := fd.stall("/channels/1/messages")
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	type result struct {
+		status int
+		body   string
+	}
+	replies := make(chan result, 1)
+	req := newRPCRequest(t, http.MethodPost, ts.URL, sessionID, strings.NewReader(
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"get_messages","arguments":{"channel_id":"1"}}}`))
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			replies <- result{}
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		replies <- result{resp.StatusCode, string(body)}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool call never reached Discord")
+	}
+	resp := postRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user aborted"}}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// The Discord call is abandoned and the cancelled request gets no reply
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Discord call was not cancelled")
+	}
+	reply := <-replies
+	assert.Equal(t, http.StatusAccepted, reply.status)
+	assert.Empty(t, strings.TrimSpace(reply.body))
+}
+
+func TestStdioTransportSkipsMalformedLines(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	server, err := mcp.NewServer(newTestConfig(), logger)
+	require.NoError(t, err)
+
+	in := strings.NewReader("{\"jsonrpc\": \"2.0\", \"id\": 1,\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}` + "\n")
+	outReader, out := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ServeStdio(in, out)
+		out.Close()
+	}()
+
+	// The broken line gets one parse error and the request after it is
+	// still answered
+	decoder := json.NewDecoder(outReader)
+	var replies []mcp.JSONRPCResponse
+	for i := 0; i < 2; i++ {
+		var reply mcp.JSONRPCResponse
+		require.NoError(t, decoder.Decode(&reply))
+		replies = append(replies, reply)
+	}
+	require.NotNil(t, replies[0].Error)
+	assert.Equal(t, mcp.ParseError, replies[0].Error.Code)
+	assert.Nil(t, replies[1].Error)
+	assert.EqualValues(t, 2, replies[1].ID)
+
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stdio transport did not stop at end of input")
+	}
+}
+
+func TestReusedRequestIDStaysCancellable(t *testing.T) {
+	fd := newFakeDiscord(t)
+	started, aborted := fd.stall("/channels/1/messages", "/channels/2/messages")
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	call := func(ctx context.Context, channelID string) {
+		req := newRPCRequest(t, http.MethodPost, ts.URL, sessionID, strings.NewReader(
+			`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"get_messages","arguments":{"channel_id":"`+channelID+`"}}}`))
+		go func() {
+			resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("tool call never reached Discord")
+		}
+	}
+	wait := func(what string) {
+		select {
+		case <-aborted:
+		case <-time.After(5 * time.Second):
+			t.Fatal(what + " was not cancelled")
+		}
+	}
+
+	// Both requests use ID 7, reading different channels as discordgo sends
+	// requests for one route in turn. The first one finishing, here by its
+	// client hanging up, must not forget the second
+	first, hangUp := context.WithCancel(context.Background())
+	call(first, "1")
+	call(context.Background(), "2")
+	hangUp()
+	wait("first call")
+
+	resp := postRPC(t, ts.URL, sessionID,
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	wait("second call")
+}
+
+func TestHTTPTransportRejectsLargeBodies(t *testing.T) {
+	fd := newFakeDiscord(t)
+	ts := newTestHTTPServer(t, newTestConfig())
+	sessionID := initializeSession(t, ts.URL)
+
+	params, err := json.Marshal(map[string]interface{}{
+		"name": "send_message",
+		"arguments": map[string]interface{}{
+			"channel_id":  "1",
+			"attachments": []interface{}{map[string]interface{}{"filename": "big.bin", "content_base64": strings.Repeat("A", 5<<20)}},
+		},
+	})
+	require.NoError(t, err)
+	resp := postRPC(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":`+string(params)+`}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Empty(t, fd.sentMessages())
+}